@@ -0,0 +1,177 @@
+// Package archive stores snapshots of web pages in a content-addressed
+// directory, so bookmarks keep a copy of their page when the link rots.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/readability"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var _ bookmark.Archiver = &Archiver{}
+
+// ErrUnknownFormat is returned when archiving in a format that does not
+// exist.
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// Format is how a page is archived.
+type Format string
+
+// Archive formats.
+const (
+	// FormatHTML stores the HTML of the page as it was served.
+	FormatHTML Format = "html"
+	// FormatSingleFile stores the HTML of the page with its stylesheets and
+	// images inlined, so it can be viewed without a network. Scripts are
+	// removed.
+	FormatSingleFile Format = "single"
+	// FormatText stores the text of the main content of the page.
+	FormatText Format = "text"
+)
+
+// FormatNames returns the names of all formats.
+func FormatNames() []string {
+	return []string{string(FormatHTML), string(FormatSingleFile), string(FormatText)}
+}
+
+// Archiver fetches pages and stores them in a directory. Snapshots are
+// named by the SHA-256 of their content and spread over subdirectories by
+// the first two characters of the hash.
+type Archiver struct {
+	dir    string
+	client *http.Client
+	format Format
+}
+
+// New creates an archiver that stores snapshots in dir in the given format.
+func New(dir string, client *http.Client, format Format) *Archiver {
+	return &Archiver{
+		dir:    dir,
+		client: client,
+		format: format,
+	}
+}
+
+// Archive fetches the page at rawURL and stores a snapshot of it. A page
+// that is already archived with the same content is not stored again.
+func (a *Archiver) Archive(ctx context.Context, rawURL string) (*bookmark.Snapshot, error) {
+	var data []byte
+	var ext string
+	switch a.format {
+	case FormatHTML:
+		body, _, _, err := a.get(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		data, ext = body, ".html"
+	case FormatSingleFile:
+		doc, pageURL, err := a.document(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		a.inline(ctx, doc, pageURL)
+		var buf bytes.Buffer
+		if err = html.Render(&buf, doc); err != nil {
+			return nil, err
+		}
+		data, ext = buf.Bytes(), ".html"
+	case FormatText:
+		doc, _, err := a.document(ctx, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		data, ext = []byte(readability.Extract(doc)), ".txt"
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, a.format)
+	}
+	return a.store(data, ext)
+}
+
+// document fetches and parses the page at rawURL. It also returns the URL
+// of the page after redirects.
+func (a *Archiver) document(ctx context.Context, rawURL string) (*html.Node, *url.URL, error) {
+	body, pageURL, contentType, err := a.get(ctx, rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported page encoding: %w", err)
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, pageURL, nil
+}
+
+// get fetches the resource at rawURL. It returns its content, its URL after
+// redirects and its content type.
+func (a *Archiver) get(ctx context.Context, rawURL string) ([]byte, *url.URL, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	req.Header.Set("User-Agent", bookmark.UserAgent)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, "", fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return body, resp.Request.URL, resp.Header.Get("Content-Type"), nil
+}
+
+// store writes a snapshot to its content address unless it already exists.
+func (a *Archiver) store(data []byte, ext string) (*bookmark.Snapshot, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	dir := filepath.Join(a.dir, hash[:2])
+	s := &bookmark.Snapshot{
+		Hash:   hash,
+		Path:   filepath.Join(dir, hash+ext),
+		Format: string(a.format),
+	}
+	if _, err := os.Stat(s.Path); err == nil {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create archive directory: %w", err)
+	}
+	// write to a temporary file first, so a snapshot is never partial
+	f, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(f.Name(), s.Path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,255 @@
+package archive_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/archive"
+)
+
+const page = `<!DOCTYPE html>
+<html><head>
+<meta charset="windows-1252">
+<title>Caf` + "\xe9" + `</title>
+<link rel="stylesheet" href="/style.css">
+<link rel="icon" href="/missing.ico">
+<script src="/app.js"></script>
+</head><body>
+<h1>Caf` + "\xe9" + `</h1>
+<p>Read <a href="/more">more</a>.</p>
+<img src="img/dot.gif" srcset="img/dot-2x.gif 2x">
+<script>alert("hi")</script>
+</body></html>`
+
+// unsafePage runs script in every way the single file format removes.
+const unsafePage = `<!DOCTYPE html>
+<html><head>
+<meta http-equiv="refresh" content="0; url=javascript:alert(1)">
+</head><body onload="alert(1)">
+<a href="javascript:alert(1)" onclick="alert(1)" title="javascript: the good parts">link</a>
+<a href=" JavaScript:alert(1)">spaced</a>
+<a href="java&#x09;script:alert(1)">tabbed</a>
+<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">send</button></form>
+<img src="javascript:alert(1)" onerror="alert(1)" ONMOUSEOVER="alert(1)">
+<svg><a xlink:href="javascript:alert(1)"><text>svg</text></a></svg>
+<iframe src="/page"></iframe>
+<iframe srcdoc="<script>alert(1)</script>"></iframe>
+<object data="/app.swf"></object>
+<embed src="/app.swf">
+<frameset><frame src="/page"></frameset>
+<p>kept</p>
+</body></html>`
+
+// gif is a 1x1 transparent GIF.
+const gif = "GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\xff\xff\xff!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;"
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	})
+	mux.HandleFunc("/unsafe", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(unsafePage))
+	})
+	mux.HandleFunc("/agent", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(r.UserAgent()))
+	})
+	mux.HandleFunc("/style.css", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = w.Write([]byte(`body { background: url('img/dot.gif'); }`))
+	})
+	mux.HandleFunc("/img/dot.gif", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/gif")
+		_, _ = w.Write([]byte(gif))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestArchiver_Archive(t *testing.T) {
+	server := newServer(t)
+
+	t.Run("html should store the page as it was served", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := archive.New(dir, &http.Client{}, archive.FormatHTML).Archive(t.Context(), server.URL+"/page")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		if want := filepath.Join(dir, s.Hash[:2], s.Hash+".html"); s.Path != want {
+			t.Errorf("Path = %q, want %q", s.Path, want)
+		}
+		if s.Format != "html" {
+			t.Errorf("Format = %q, want html", s.Format)
+		}
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != page {
+			t.Errorf("stored %q, want the page", data)
+		}
+	})
+
+	t.Run("archive should send the user agent of bookmarks", func(t *testing.T) {
+		s, err := archive.New(t.TempDir(), &http.Client{}, archive.FormatHTML).Archive(t.Context(), server.URL+"/agent")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != bookmark.UserAgent {
+			t.Errorf("User-Agent = %q, want %q", data, bookmark.UserAgent)
+		}
+	})
+
+	t.Run("equal pages should be stored once", func(t *testing.T) {
+		dir := t.TempDir()
+		a := archive.New(dir, &http.Client{}, archive.FormatHTML)
+		first, err := a.Archive(t.Context(), server.URL+"/page")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		second, err := a.Archive(t.Context(), server.URL+"/page?again")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		if first.Path != second.Path {
+			t.Errorf("paths %q and %q differ", first.Path, second.Path)
+		}
+		entries, err := os.ReadDir(filepath.Dir(first.Path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("archive has %d files, want 1", len(entries))
+		}
+	})
+
+	t.Run("single file should inline resources and drop scripts", func(t *testing.T) {
+		s, err := archive.New(t.TempDir(), &http.Client{}, archive.FormatSingleFile).Archive(t.Context(), server.URL+"/page")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := string(data)
+		for _, want := range []string{
+			`<meta charset="utf-8"/>`,
+			`<base href="` + server.URL + `/page"/>`,
+			"<title>Café</title>",
+			`<style>body { background: url("data:image/gif;base64,`,
+			`<img src="data:image/gif;base64,`,
+			`<link rel="icon" href="` + server.URL + `/missing.ico"/>`,
+			`<a href="/more">`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("snapshot does not contain %q:\n%s", want, got)
+			}
+		}
+		for _, unwanted := range []string{"<script", "srcset", "windows-1252", "style.css"} {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("snapshot contains %q:\n%s", unwanted, got)
+			}
+		}
+	})
+
+	singleFile := func(t *testing.T) string {
+		t.Helper()
+		s, err := archive.New(t.TempDir(), &http.Client{}, archive.FormatSingleFile).Archive(t.Context(), server.URL+"/unsafe")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<p>kept</p>") {
+			t.Errorf("snapshot lost the content of the page:\n%s", data)
+		}
+		return string(data)
+	}
+
+	t.Run("single file should drop event handlers", func(t *testing.T) {
+		got := strings.ToLower(singleFile(t))
+		for _, unwanted := range []string{"onload", "onclick", "onerror", "onmouseover"} {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("snapshot contains %q:\n%s", unwanted, got)
+			}
+		}
+	})
+
+	t.Run("single file should drop javascript urls", func(t *testing.T) {
+		got := singleFile(t)
+		if n := strings.Count(strings.ToLower(got), "javascript:"); n != 1 {
+			t.Errorf("snapshot contains %d javascript: urls, want only the title:\n%s", n-1, got)
+		}
+		for _, want := range []string{
+			`title="javascript: the good parts"`,
+			"<form>",
+			"<button>send</button>",
+			">tabbed</a>",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("snapshot does not contain %q:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("single file should drop embedded frames, objects and plugins", func(t *testing.T) {
+		got := singleFile(t)
+		for _, unwanted := range []string{"<iframe", "<object", "<embed", "<frame", "app.swf", "http-equiv"} {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("snapshot contains %q:\n%s", unwanted, got)
+			}
+		}
+	})
+
+	t.Run("text should store the visible text", func(t *testing.T) {
+		s, err := archive.New(t.TempDir(), &http.Client{}, archive.FormatText).Archive(t.Context(), server.URL+"/page")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Café\nRead more.\n"; string(data) != want {
+			t.Errorf("stored %q, want %q", data, want)
+		}
+		if filepath.Ext(s.Path) != ".txt" {
+			t.Errorf("Path = %q, want a .txt file", s.Path)
+		}
+	})
+
+	t.Run("missing pages should fail", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := archive.New(dir, &http.Client{}, archive.FormatHTML).Archive(t.Context(), server.URL+"/missing"); err == nil {
+			t.Error("Archive() should fail")
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("archive has %d entries, want none", len(entries))
+		}
+	})
+
+	t.Run("unknown formats should fail", func(t *testing.T) {
+		_, err := archive.New(t.TempDir(), &http.Client{}, "pdf").Archive(t.Context(), server.URL+"/page")
+		if !errors.Is(err, archive.ErrUnknownFormat) {
+			t.Errorf("Archive() error = %v, want %v", err, archive.ErrUnknownFormat)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package archive
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssURL matches the url() references of stylesheets.
+//
+//nolint:gochecknoglobals // compiled once
+var cssURL = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+
+// inline replaces the stylesheets, images and icons of a document by their
+// content and removes everything that runs code: scripts, event handlers,
+// javascript: URLs and embedded frames, objects and plugins. Resources that
+// cannot be fetched keep their absolute URL. Links resolve against the
+// original page.
+func (a *Archiver) inline(ctx context.Context, doc *html.Node, pageURL *url.URL) {
+	base := pageURL
+	if n := find(doc, atom.Base); n != nil {
+		if u, err := pageURL.Parse(attr(n, "href")); err == nil {
+			base = u
+		}
+	}
+	var remove []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			removeScriptAttrs(n)
+			switch n.DataAtom {
+			case atom.Script, atom.Base, atom.Iframe, atom.Frame, atom.Frameset, atom.Object, atom.Embed, atom.Applet:
+				remove = append(remove, n)
+				return
+			case atom.Meta:
+				// the document is rendered as UTF-8, and is not redirected
+				equiv := strings.ToLower(attr(n, "http-equiv"))
+				if hasAttr(n, "charset") || equiv == "content-type" || equiv == "refresh" {
+					remove = append(remove, n)
+				}
+			case atom.Link:
+				a.inlineLink(ctx, n, base)
+			case atom.Style:
+				if c := n.FirstChild; c != nil && c.Type == html.TextNode {
+					c.Data = a.inlineCSS(ctx, c.Data, base)
+				}
+			case atom.Img:
+				a.inlineAttr(ctx, n, "src", base)
+				// the inlined source replaces all candidates
+				removeAttr(n, "srcset")
+			}
+			if style := attr(n, "style"); style != "" {
+				setAttr(n, "style", a.inlineCSS(ctx, style, base))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	for _, n := range remove {
+		n.Parent.RemoveChild(n)
+	}
+	if head := find(doc, atom.Head); head != nil {
+		head.InsertBefore(element(atom.Base, "href", base.String()), head.FirstChild)
+		head.InsertBefore(element(atom.Meta, "charset", "utf-8"), head.FirstChild)
+	}
+}
+
+// urlAttrs are the attributes whose value is a URL.
+//
+//nolint:gochecknoglobals // static list of attributes
+var urlAttrs = []string{"href", "src", "action", "formaction", "xlink:href", "data", "poster", "background"}
+
+// removeScriptAttrs removes the event handlers and javascript: URLs of an
+// element.
+func removeScriptAttrs(n *html.Node) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		return strings.HasPrefix(key, "on") || slices.Contains(urlAttrs, key) && isJavaScriptURL(a.Val)
+	})
+}
+
+// isJavaScriptURL reports whether a value is a javascript: URL. Browsers
+// ignore whitespace and control characters in the scheme, so they are
+// ignored here too.
+func isJavaScriptURL(v string) bool {
+	scheme := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, v)
+	return len(scheme) >= len("javascript:") && strings.EqualFold(scheme[:len("javascript:")], "javascript:")
+}
+
+// inlineLink inlines the stylesheet or icon of a link element.
+func (a *Archiver) inlineLink(ctx context.Context, n *html.Node, base *url.URL) {
+	rel := strings.Fields(strings.ToLower(attr(n, "rel")))
+	switch {
+	case slices.Contains(rel, "stylesheet"):
+		ref, err := base.Parse(attr(n, "href"))
+		if err != nil {
+			return
+		}
+		data, sheetURL, _, err := a.get(ctx, ref.String())
+		if err != nil {
+			setAttr(n, "href", ref.String())
+			return
+		}
+		// replace the link by a style element with the same media
+		media := attr(n, "media")
+		n.Data, n.DataAtom, n.Attr = "style", atom.Style, nil
+		if media != "" {
+			setAttr(n, "media", media)
+		}
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: a.inlineCSS(ctx, string(data), sheetURL)})
+	case slices.Contains(rel, "icon") || slices.Contains(rel, "apple-touch-icon"):
+		a.inlineAttr(ctx, n, "href", base)
+	}
+}
+
+// inlineAttr replaces the URL in an attribute by a data URI.
+func (a *Archiver) inlineAttr(ctx context.Context, n *html.Node, key string, base *url.URL) {
+	if v := attr(n, key); v != "" {
+		setAttr(n, key, a.dataURI(ctx, v, base))
+	}
+}
+
+// inlineCSS replaces the url() references of a stylesheet by data URIs.
+func (a *Archiver) inlineCSS(ctx context.Context, css string, base *url.URL) string {
+	return cssURL.ReplaceAllStringFunc(css, func(m string) string {
+		ref := cssURL.FindStringSubmatch(m)[2]
+		return `url("` + a.dataURI(ctx, ref, base) + `")`
+	})
+}
+
+// dataURI fetches a resource and returns it as data URI. It returns the
+// absolute URL of the resource when it cannot be fetched.
+func (a *Archiver) dataURI(ctx context.Context, ref string, base *url.URL) string {
+	if strings.HasPrefix(ref, "data:") {
+		return ref
+	}
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	data, _, contentType, err := a.get(ctx, u.String())
+	if err != nil {
+		return u.String()
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// find returns the first element of a document with the given atom.
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// element returns a new element with one attribute.
+func element(a atom.Atom, key, val string) *html.Node {
+	return &html.Node{
+		Type:     html.ElementNode,
+		Data:     a.String(),
+		DataAtom: a,
+		Attr:     []html.Attribute{{Key: key, Val: val}},
+	}
+}
+
+// attr returns the value of an attribute of an element.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether an element has an attribute.
+func hasAttr(n *html.Node, key string) bool {
+	return slices.ContainsFunc(n.Attr, func(a html.Attribute) bool { return a.Key == key })
+}
+
+// setAttr sets the value of an attribute of an element.
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// removeAttr removes an attribute from an element.
+func removeAttr(n *html.Node, key string) {
+	n.Attr = slices.DeleteFunc(n.Attr, func(a html.Attribute) bool { return a.Key == key })
+}
@@ -2,76 +2,378 @@ package bookmark
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	// ErrNotFound is returned when a bookmark is not found.
+	ErrNotFound = errors.New("bookmark not found")
+	// ErrDuplicate is returned when adding a URL that is already
+	// bookmarked. The error is a *DuplicateError.
+	ErrDuplicate = errors.New("url is already bookmarked")
+)
+
+// DuplicateError is returned when adding a URL that is already bookmarked.
+// It matches ErrDuplicate.
+type DuplicateError struct {
+	// Existing is the bookmark of the URL.
+	Existing *Bookmark
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s as %s", ErrDuplicate, e.Existing.ID)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
 // Bookmark is a struct that represents a bookmark.
 type Bookmark struct {
-	Title     string
-	Content   string
+	ID      string
+	Title   string
+	Content string
+	Tags    []string
+	// Collection is the path of the collection the bookmark is in, empty
+	// when it is in none.
+	Collection string
+	Metadata   Metadata
+	// PendingMetadata is set when the metadata of the URL could not be
+	// fetched yet. Refresh resolves pending bookmarks.
+	PendingMetadata bool
+	// Health is the result of the last link check.
+	Health Health
+	// Snapshot is the latest archived copy of the page.
+	Snapshot  Snapshot
 	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // Store is an interface that represents a bookmark store.
+//
+// Bookmarks are identified by their ID. Add assigns a new ID to bookmarks
+// that do not have one yet. Update applies the patch to the stored bookmark
+// and returns the updated bookmark.
 type Store interface {
 	Add(b *Bookmark) error
+	Get(id string) (*Bookmark, error)
+	Update(id string, p *Patch) (*Bookmark, error)
 	List() ([]*Bookmark, error)
-	Delete(title string) error
+	Delete(id string) error
 }
 
 // Library is a struct that represents a bookmark library.
 type Library struct {
-	logger *slog.Logger
-	store  Store
-	client *http.Client
+	logger  *slog.Logger
+	store   Store
+	fetcher Fetcher
+	// client is used to check links.
+	client  *http.Client
+	now     func() time.Time
+	lenient bool
+	offline bool
+	// archiver archives pages, on add when archiveOnAdd is set.
+	archiver     Archiver
+	archiveOnAdd bool
+	// index is kept in sync with the store when it is set. indexMu
+	// serializes the changes that keep it in sync.
+	index   Index
+	indexMu sync.Mutex
+	// canonicalizer finds the duplicates of new bookmarks, which are only
+	// added when allowDuplicates is set.
+	canonicalizer   Canonicalizer
+	allowDuplicates bool
+}
+
+// Option configures a Library.
+type Option func(*Library)
+
+// WithFetcher sets the fetcher used to fetch the metadata of URLs.
+func WithFetcher(f Fetcher) Option {
+	return func(l *Library) {
+		l.fetcher = f
+	}
+}
+
+// WithHTTPClient fetches metadata and checks links with the given HTTP
+// client. It replaces the fetcher of the library.
+func WithHTTPClient(c *http.Client) Option {
+	return func(l *Library) {
+		l.fetcher = &HTTPFetcher{Client: c}
+		l.client = c
+	}
+}
+
+// WithClock sets the function that returns the current time, which is used
+// for timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(l *Library) {
+		l.now = now
+	}
+}
+
+// WithLenientFetch makes failing to fetch metadata non-fatal. Bookmarks of
+// URLs without a title are then saved with the URL as their title.
+func WithLenientFetch() Option {
+	return func(l *Library) {
+		l.lenient = true
+	}
+}
+
+// WithOffline never fetches metadata when adding bookmarks. Bookmarks of
+// URLs are saved with their metadata pending, and with the URL as their
+// title when they have none.
+func WithOffline() Option {
+	return func(l *Library) {
+		l.offline = true
+	}
 }
 
-func NewLibrary(logger *slog.Logger, store Store) *Library {
-	return &Library{
-		logger: logger,
-		store:  store,
-		client: &http.Client{},
+// WithCanonicalizer sets the canonicalizer used to find duplicates of new
+// bookmarks.
+func WithCanonicalizer(c Canonicalizer) Option {
+	return func(l *Library) {
+		l.canonicalizer = c
 	}
 }
 
-// Add adds a bookmark to the library.
+// WithAllowDuplicates adds bookmarks of URLs that are already bookmarked
+// instead of failing with ErrDuplicate.
+func WithAllowDuplicates() Option {
+	return func(l *Library) {
+		l.allowDuplicates = true
+	}
+}
+
+// NewLibrary creates a new library. By default metadata is fetched with an
+// HTTP client that follows DefaultFetchPolicy, and duplicates are found
+// with DefaultCanonicalizer.
+func NewLibrary(logger *slog.Logger, store Store, opts ...Option) *Library {
+	client := NewHTTPClient(DefaultFetchPolicy())
+	l := &Library{
+		logger:        logger,
+		store:         store,
+		fetcher:       &HTTPFetcher{Client: client},
+		client:        client,
+		now:           time.Now,
+		canonicalizer: DefaultCanonicalizer(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Close closes the store and the index of the library if they need
+// closing.
+func (l *Library) Close() error {
+	var errs []error
+	if c, ok := l.index.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	if c, ok := l.store.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Add adds a bookmark to the library. If the content is a URL, it is
+// normalized with NormalizeURL and a *DuplicateError is returned when its
+// canonical form is already bookmarked, unless duplicates are allowed. Then
+// the metadata of the page is fetched and its title is used when the
+// bookmark has none.
+// Fetching may only fail when the bookmark already has a title, unless the
+// library is lenient. Bookmarks whose metadata could not be fetched are
+// saved with their metadata pending. Bookmarks without a creation time are
+// created now.
 func (l *Library) Add(ctx context.Context, b *Bookmark) error {
-	if b.Title == "" && isURL(b.Content) {
-		title, err := FetchTitle(ctx, l.client, b.Content)
-		if err != nil {
+	if isURL(b.Content) {
+		// a URL that cannot be parsed is kept as it is
+		if u, err := NormalizeURL(b.Content); err == nil {
+			b.Content = u
+		}
+		if err := l.checkDuplicate(b.Content); err != nil {
 			return err
 		}
-		b.Title = title
+		if err := l.addMetadata(ctx, b); err != nil {
+			return err
+		}
+	}
+	if isURL(b.Content) && l.archiveOnAdd && l.archiver != nil && !l.offline {
+		// a page that cannot be archived is still worth bookmarking
+		if s, err := l.archive(ctx, b.Content); err != nil {
+			l.logger.WarnContext(ctx, "could not archive page", slog.String("url", b.Content), slog.Any("error", err))
+		} else {
+			b.Snapshot = *s
+		}
+	}
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = l.now()
+	}
+	b.Tags = NormalizeTags(b.Tags)
+	b.Collection = NormalizeCollection(b.Collection)
+	if err := l.keepCollection(b.Collection); err != nil {
+		return err
 	}
-	return l.store.Add(b)
+	if err := l.change(func() error { return l.store.Add(b) }); err != nil {
+		return err
+	}
+	l.indexPut(b)
+	return nil
 }
 
-// List lists all bookmarks in the library.
-func (l *Library) List() ([]*Bookmark, error) {
-	return l.store.List()
+// checkDuplicate returns a *DuplicateError when the URL is already
+// bookmarked and duplicates are not allowed.
+func (l *Library) checkDuplicate(url string) error {
+	if l.allowDuplicates {
+		return nil
+	}
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return err
+	}
+	key := l.canonicalizer.Key(url)
+	for _, b := range bookmarks {
+		if isURL(b.Content) && l.canonicalizer.Key(b.Content) == key {
+			return &DuplicateError{Existing: b}
+		}
+	}
+	return nil
+}
+
+// addMetadata fetches the metadata of the URL of a new bookmark and sets its
+// title when it has none.
+func (l *Library) addMetadata(ctx context.Context, b *Bookmark) error {
+	if l.offline {
+		b.PendingMetadata = true
+	} else {
+		md, err := l.fetcher.Fetch(ctx, b.Content)
+		switch {
+		case err != nil && b.Title == "" && !l.lenient:
+			return err
+		case err != nil:
+			l.logger.WarnContext(ctx, "could not fetch metadata", slog.String("url", b.Content), slog.Any("error", err))
+			b.PendingMetadata = true
+		default:
+			b.Metadata = *md
+			b.Metadata.FetchedAt = l.now()
+		}
+	}
+	if b.Title == "" {
+		b.Title = b.Metadata.Title
+	}
+	if b.Title == "" {
+		if !l.lenient && !b.PendingMetadata {
+			return ErrTitleNotFound
+		}
+		b.Title = b.Content
+	}
+	return nil
+}
+
+// Get gets the bookmark with the given ID from the library.
+func (l *Library) Get(id string) (*Bookmark, error) {
+	return l.store.Get(id)
+}
+
+// Update applies the patch to the bookmark with the given ID. If the patch
+// has no UpdatedAt timestamp, the current time is used. The collection a
+// patch moves the bookmark to is kept, like Add does. The patch itself is
+// not changed, so it can be applied again.
+func (l *Library) Update(id string, patch *Patch) (*Bookmark, error) {
+	p := *patch
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = l.now()
+	}
+	if p.Collection != nil {
+		if err := l.keepCollection(NormalizeCollection(*p.Collection)); err != nil {
+			return nil, err
+		}
+	}
+	var b *Bookmark
+	err := l.change(func() error {
+		var err error
+		b, err = l.store.Update(id, &p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.indexPut(b)
+	return b, nil
 }
 
-// Search searches for bookmarks in the library.
-func (l *Library) Search(query string) ([]*Bookmark, error) {
+// Resolve finds the bookmarks that a reference points to. A reference is
+// either the ID of a bookmark or its title. Because titles are not unique,
+// more than one bookmark can be returned. ErrNotFound is returned when
+// nothing matches.
+func (l *Library) Resolve(ref string) ([]*Bookmark, error) {
+	b, err := l.store.Get(ref)
+	if err == nil {
+		return []*Bookmark{b}, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
 	bookmarks, err := l.store.List()
 	if err != nil {
 		return nil, err
 	}
 	var results []*Bookmark
 	for _, b := range bookmarks {
-		if strings.Contains(b.Title, query) || strings.Contains(b.Content, query) {
+		if b.Title == ref {
 			results = append(results, b)
 		}
 	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
 	return results, nil
 }
 
-// Delete deletes a bookmark from the library.
-func (l *Library) Delete(title string) error {
-	return l.store.Delete(title)
+// List lists all bookmarks in the library.
+func (l *Library) List() ([]*Bookmark, error) {
+	return l.store.List()
+}
+
+// Tags lists all tags in the library with the number of bookmarks that have
+// them, most used first.
+func (l *Library) Tags() ([]TagCount, error) {
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	return countTags(bookmarks), nil
+}
+
+// Tag adds the given tags to the bookmark with the given ID.
+func (l *Library) Tag(id string, tags ...string) (*Bookmark, error) {
+	return l.Update(id, &Patch{AddTags: tags})
+}
+
+// Untag removes the given tags from the bookmark with the given ID.
+func (l *Library) Untag(id string, tags ...string) (*Bookmark, error) {
+	return l.Update(id, &Patch{RemoveTags: tags})
+}
+
+// Delete deletes the bookmark with the given ID from the library.
+func (l *Library) Delete(id string) error {
+	if err := l.change(func() error { return l.store.Delete(id) }); err != nil {
+		return err
+	}
+	if l.index != nil {
+		if err := l.index.Delete(id); err != nil {
+			l.logger.Warn("could not remove bookmark from index", slog.String("id", id), slog.Any("error", err))
+		}
+	}
+	return nil
 }
 
 // isURL checks if a string is a URL.
@@ -0,0 +1,374 @@
+package bookmark_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibrary_Resolve(t *testing.T) {
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	for _, b := range []*bookmark.Bookmark{
+		{ID: "1", Title: "Same", Content: "a"},
+		{ID: "2", Title: "Same", Content: "b"},
+		{ID: "3", Title: "Other", Content: "c"},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantIDs []string
+		wantErr error
+	}{
+		{name: "by id", ref: "3", wantIDs: []string{"3"}},
+		{name: "by title", ref: "Same", wantIDs: []string{"1", "2"}},
+		{name: "not found", ref: "Missing", wantErr: bookmark.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lib.Resolve(tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Library.Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, b := range got {
+				ids = append(ids, b.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("Library.Resolve() = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("Library.Resolve() = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestLibrary_Search(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	for _, b := range []*bookmark.Bookmark{
+		{ID: "1", Title: "Go blog", Content: "https://go.dev/blog", Tags: []string{"go"},
+			CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Title: "Go playground", Content: "https://go.dev/play", Tags: []string{"go", "tools"},
+			CreatedAt: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: "3", Title: "Rust blog", Content: "https://blog.rust-lang.org", Tags: []string{"rust"},
+			CreatedAt: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), UpdatedAt: now},
+		{ID: "4", Title: "Extending clusters", Content: "https://example.com/operators", Metadata: bookmark.Metadata{
+			Text: "A Kubernetes operator reconciles custom resources.",
+		}},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store, bookmark.WithClock(func() time.Time { return now }))
+
+	tests := []struct {
+		name    string
+		query   string
+		mode    query.Mode
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "empty", query: "", wantIDs: []string{"1", "2", "3", "4"}},
+		{name: "text", query: "blog", wantIDs: []string{"1", "3"}},
+		{name: "text ignores case", query: "BLOG", wantIDs: []string{"1", "3"}},
+		{name: "fuzzy", query: "gplg", mode: query.ModeFuzzy, wantIDs: []string{"2"}},
+		{name: "fuzzy skips page text", query: "kubernetes", mode: query.ModeFuzzy, wantIDs: nil},
+		{name: "fuzzy phrase", query: `"rust blog"`, mode: query.ModeFuzzy, wantIDs: []string{"3"}},
+		{name: "regex", query: "^go", mode: query.ModeRegex, wantIDs: []string{"1", "2"}},
+		{name: "regex field", query: `url:"\.org$"`, mode: query.ModeRegex, wantIDs: []string{"3"}},
+		{name: "invalid regex", query: "(go", mode: query.ModeRegex, wantErr: true},
+		{name: "tag", query: "tag:go", wantIDs: []string{"1", "2"}},
+		{name: "multiple tags", query: "tag:go tag:tools", wantIDs: []string{"2"}},
+		{name: "tag and text", query: "tag:go blog", wantIDs: []string{"1"}},
+		{name: "unknown tag", query: "tag:python", wantIDs: nil},
+		{name: "any tag", query: "tag:*", wantIDs: []string{"1", "2", "3"}},
+		{name: "untagged", query: "-tag:*", wantIDs: []string{"4"}},
+		{name: "page text", query: "kubernetes operator", wantIDs: []string{"4"}},
+		{name: "page text needs every term", query: "kubernetes rust", wantIDs: nil},
+		{name: "title", query: "title:blog", wantIDs: []string{"1", "3"}},
+		{name: "url", query: "url:play", wantIDs: []string{"2"}},
+		{name: "site", query: "site:rust-lang.org", wantIDs: []string{"3"}},
+		{name: "site matches whole labels", query: "site:lang.org", wantIDs: nil},
+		{name: "phrase", query: `"go blog"`, wantIDs: []string{"1"}},
+		{name: "or", query: "tag:rust OR playground", wantIDs: []string{"2", "3"}},
+		{name: "not", query: "blog -tag:go", wantIDs: []string{"3"}},
+		{name: "groups", query: "(tag:go OR tag:rust) NOT blog", wantIDs: []string{"2"}},
+		{name: "created after", query: "created:>2025-01-10", wantIDs: []string{"3"}},
+		{name: "created in month", query: "created:2025-01", wantIDs: []string{"2"}},
+		{name: "created range", query: "created:2024..2025-01", wantIDs: []string{"1", "2"}},
+		{name: "created last week", query: "created:last-week", wantIDs: []string{"3"}},
+		{name: "updated today", query: "updated:today", wantIDs: []string{"3"}},
+		{name: "syntax error", query: "(go", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lib.Search(tt.query, bookmark.SearchOptions{Mode: tt.mode})
+			var qErr *query.Error
+			if tt.wantErr != errors.As(err, &qErr) {
+				t.Fatalf("Library.Search() error = %v, want query error %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, r := range got {
+				ids = append(ids, r.Bookmark.ID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLibrary_Tags(t *testing.T) {
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	for _, b := range []*bookmark.Bookmark{
+		{Title: "a", Tags: []string{"go"}},
+		{Title: "b", Tags: []string{"go", "web"}},
+		{Title: "c", Tags: []string{"api"}},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+	got, err := lib.Tags()
+	if err != nil {
+		t.Fatalf("Library.Tags() error = %v", err)
+	}
+	want := []bookmark.TagCount{
+		{Tag: "go", Count: 2},
+		{Tag: "api", Count: 1},
+		{Tag: "web", Count: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Library.Tags() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLibrary_Add(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<title>Example Domain</title><meta name="description" content="An example">`))
+	}))
+	defer server.Close()
+
+	t.Run("add should fetch the title and metadata of a url", func(t *testing.T) {
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), json.NewStore(path.Join(t.TempDir(), "test.json")))
+		b := &bookmark.Bookmark{Content: server.URL}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		got, err := lib.Get(b.ID)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		if got.Title != "Example Domain" || got.Metadata.Description != "An example" {
+			t.Errorf("Library.Add() stored %+v, want title and description of the page", got)
+		}
+	})
+
+	t.Run("add should keep a given title when fetching fails", func(t *testing.T) {
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), json.NewStore(path.Join(t.TempDir(), "test.json")))
+		b := &bookmark.Bookmark{Title: "Offline", Content: server.URL + "/missing\x7f"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if b.Title != "Offline" {
+			t.Errorf("Library.Add() title = %q, want %q", b.Title, "Offline")
+		}
+	})
+
+	t.Run("add should use the fetcher of the library", func(t *testing.T) {
+		var fetched []string
+		fetcher := bookmark.FetcherFunc(func(_ context.Context, url string) (*bookmark.Metadata, error) {
+			fetched = append(fetched, url)
+			return &bookmark.Metadata{Title: "Fake", SiteName: "fake.test"}, nil
+		})
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithFetcher(fetcher),
+		)
+		b := &bookmark.Bookmark{Content: "https://fake.test/page"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if diff := cmp.Diff([]string{"https://fake.test/page"}, fetched); diff != "" {
+			t.Errorf("fetched urls mismatch (-want +got):\n%s", diff)
+		}
+		if b.Title != "Fake" || b.Metadata.SiteName != "fake.test" {
+			t.Errorf("Library.Add() stored %+v, want metadata of the fetcher", b)
+		}
+	})
+
+	t.Run("add should use the clock of the library", func(t *testing.T) {
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithClock(func() time.Time { return now }),
+		)
+		b := &bookmark.Bookmark{Title: "Note", Content: "some text"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if !b.CreatedAt.Equal(now) {
+			t.Errorf("Library.Add() CreatedAt = %v, want %v", b.CreatedAt, now)
+		}
+		updated, err := lib.Update(b.ID, &bookmark.Patch{})
+		if err != nil {
+			t.Fatalf("Library.Update() error = %v", err)
+		}
+		if !updated.UpdatedAt.Equal(now) {
+			t.Errorf("Library.Update() UpdatedAt = %v, want %v", updated.UpdatedAt, now)
+		}
+	})
+
+	t.Run("add should fail when fetching fails and there is no title", func(t *testing.T) {
+		errFetch := errors.New("no network")
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithFetcher(bookmark.FetcherFunc(func(context.Context, string) (*bookmark.Metadata, error) {
+				return nil, errFetch
+			})),
+		)
+		if err := lib.Add(t.Context(), &bookmark.Bookmark{Content: "https://example.com"}); !errors.Is(err, errFetch) {
+			t.Errorf("Library.Add() error = %v, want %v", err, errFetch)
+		}
+	})
+
+	t.Run("lenient add should use the url as title", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			fetcher bookmark.FetcherFunc
+		}{
+			{
+				name: "fetch error",
+				fetcher: func(context.Context, string) (*bookmark.Metadata, error) {
+					return nil, errors.New("no network")
+				},
+			},
+			{
+				name: "no title",
+				fetcher: func(context.Context, string) (*bookmark.Metadata, error) {
+					return &bookmark.Metadata{}, nil
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				lib := bookmark.NewLibrary(
+					slog.New(slog.DiscardHandler),
+					json.NewStore(path.Join(t.TempDir(), "test.json")),
+					bookmark.WithFetcher(tt.fetcher),
+					bookmark.WithLenientFetch(),
+				)
+				b := &bookmark.Bookmark{Content: "https://example.com"}
+				if err := lib.Add(t.Context(), b); err != nil {
+					t.Fatalf("Library.Add() error = %v", err)
+				}
+				got, err := lib.Get(b.ID)
+				if err != nil {
+					t.Fatalf("Library.Get() error = %v", err)
+				}
+				if got.Title != "https://example.com" {
+					t.Errorf("Library.Add() title = %q, want the url", got.Title)
+				}
+			})
+		}
+	})
+
+	t.Run("add should return the existing bookmark of a duplicate url", func(t *testing.T) {
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithOffline(),
+		)
+		existing := &bookmark.Bookmark{Title: "Example", Content: "https://Example.com/page/?utm_source=feed"}
+		if err := lib.Add(t.Context(), existing); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if want := "https://example.com/page/?utm_source=feed"; existing.Content != want {
+			t.Errorf("Library.Add() content = %q, want %q", existing.Content, want)
+		}
+		err := lib.Add(t.Context(), &bookmark.Bookmark{Content: "http://example.com:80/page"})
+		var dup *bookmark.DuplicateError
+		if !errors.As(err, &dup) || !errors.Is(err, bookmark.ErrDuplicate) {
+			t.Fatalf("Library.Add() error = %v, want %v", err, bookmark.ErrDuplicate)
+		}
+		if dup.Existing.ID != existing.ID {
+			t.Errorf("DuplicateError.Existing = %s, want %s", dup.Existing.ID, existing.ID)
+		}
+	})
+
+	t.Run("add should add duplicates when they are allowed", func(t *testing.T) {
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithOffline(),
+			bookmark.WithAllowDuplicates(),
+		)
+		for range 2 {
+			if err := lib.Add(t.Context(), &bookmark.Bookmark{Content: "https://example.com/"}); err != nil {
+				t.Fatalf("Library.Add() error = %v", err)
+			}
+		}
+		bookmarks, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		if len(bookmarks) != 2 {
+			t.Errorf("Library.List() = %d bookmarks, want 2", len(bookmarks))
+		}
+	})
+}
+
+func TestLibrary_Update(t *testing.T) {
+	t.Run("update should not change the patch", func(t *testing.T) {
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithClock(func() time.Time { return now }),
+		)
+		b := &bookmark.Bookmark{Title: "Note", Content: "some text"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		title := "Renamed"
+		p := &bookmark.Patch{Title: &title}
+		for range 2 {
+			now = now.Add(time.Hour)
+			updated, err := lib.Update(b.ID, p)
+			if err != nil {
+				t.Fatalf("Library.Update() error = %v", err)
+			}
+			if !updated.UpdatedAt.Equal(now) {
+				t.Errorf("Library.Update() UpdatedAt = %v, want %v", updated.UpdatedAt, now)
+			}
+		}
+		if !p.UpdatedAt.IsZero() {
+			t.Errorf("Patch.UpdatedAt = %v, want it unchanged", p.UpdatedAt)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package bookmark
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// defaultPorts are the ports that are removed from URLs of the scheme.
+//
+//nolint:gochecknoglobals // static map of ports
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// Canonicalizer rewrites URLs to a canonical form, so the same page is not
+// bookmarked twice. The scheme and host are always lowercased and default
+// ports are removed, the other rules are configurable. The canonical form
+// is only used to compare URLs, bookmarks keep the URL they were added
+// with.
+type Canonicalizer struct {
+	// RemoveParams are the query parameters that are removed, like
+	// tracking parameters. A name ending with * removes every parameter
+	// that starts with the rest of the name.
+	RemoveParams []string
+	// SortParams sorts the query parameters by name. Values of the same
+	// parameter keep their order.
+	SortParams bool
+	// RemoveTrailingSlash removes the slash at the end of the path, also
+	// when the path is only a slash.
+	RemoveTrailingSlash bool
+	// RemoveFragment removes the fragment. Fragments often only point into
+	// a page, but some sites use them to route between pages.
+	RemoveFragment bool
+	// IgnoreScheme makes the http and https URLs of a page duplicates of
+	// each other. The scheme of the URL is kept.
+	IgnoreScheme bool
+}
+
+// DefaultCanonicalizer returns the canonicalizer used when none is
+// configured. It removes the common tracking parameters utm_*, fbclid and
+// gclid, sorts the query, removes trailing slashes and ignores the scheme.
+func DefaultCanonicalizer() Canonicalizer {
+	return Canonicalizer{
+		RemoveParams:        []string{"utm_*", "fbclid", "gclid"},
+		SortParams:          true,
+		RemoveTrailingSlash: true,
+		IgnoreScheme:        true,
+	}
+}
+
+// Canonicalize returns the canonical form of a URL.
+func (c Canonicalizer) Canonicalize(rawURL string) (string, error) {
+	u, err := parseNormalized(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if c.RemoveTrailingSlash {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+	u.RawQuery = c.query(u.RawQuery)
+	u.ForceQuery = false
+	if c.RemoveFragment {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return u.String(), nil
+}
+
+// NormalizeURL lowercases the scheme and host of a URL and removes its
+// default port. Unlike Canonicalize it never changes which page the URL
+// points to.
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := parseNormalized(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// parseNormalized parses a URL with a lowercase scheme and host and without
+// a default port.
+func parseNormalized(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" && port == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	return u, nil
+}
+
+// Key returns the key of a URL that duplicates have in common. URLs that
+// cannot be parsed are their own key.
+func (c Canonicalizer) Key(rawURL string) string {
+	u, err := c.Canonicalize(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if c.IgnoreScheme {
+		u = strings.Replace(u, "http://", "https://", 1)
+	}
+	return u
+}
+
+// query removes and sorts the parameters of a raw query. Parameters keep
+// their encoding.
+func (c Canonicalizer) query(rawQuery string) string {
+	var params []string
+	for _, p := range strings.Split(rawQuery, "&") {
+		if p != "" && !c.removeParam(paramName(p)) {
+			params = append(params, p)
+		}
+	}
+	if c.SortParams {
+		slices.SortStableFunc(params, func(a, b string) int {
+			return strings.Compare(paramName(a), paramName(b))
+		})
+	}
+	return strings.Join(params, "&")
+}
+
+// removeParam reports whether the parameter with the given name is removed.
+func (c Canonicalizer) removeParam(name string) bool {
+	for _, r := range c.RemoveParams {
+		if prefix, ok := strings.CutSuffix(r, "*"); (ok && strings.HasPrefix(name, prefix)) || name == r {
+			return true
+		}
+	}
+	return false
+}
+
+// paramName returns the unescaped name of a query parameter.
+func paramName(param string) string {
+	name, _, _ := strings.Cut(param, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
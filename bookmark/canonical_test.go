@@ -0,0 +1,114 @@
+package bookmark_test
+
+import (
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+func TestCanonicalizer_Canonicalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		canonicalizer bookmark.Canonicalizer
+		url           string
+		want          string
+	}{
+		{
+			name:          "canonicalize should lowercase the scheme and host",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "HTTPS://Example.COM/Path",
+			want:          "https://example.com/Path",
+		},
+		{
+			name:          "canonicalize should remove default ports",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "https://example.com:443/a",
+			want:          "https://example.com/a",
+		},
+		{
+			name:          "canonicalize should keep other ports",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "https://example.com:8443/a",
+			want:          "https://example.com:8443/a",
+		},
+		{
+			name:          "canonicalize should remove trailing slashes",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "https://example.com/docs/",
+			want:          "https://example.com/docs",
+		},
+		{
+			name:          "canonicalize should remove tracking parameters and sort the query",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "https://example.com/?utm_source=x&b=2&fbclid=y&a=1&b=1&gclid=z&",
+			want:          "https://example.com?a=1&b=2&b=1",
+		},
+		{
+			name:          "canonicalize should keep the fragment by default",
+			canonicalizer: bookmark.DefaultCanonicalizer(),
+			url:           "https://example.com/spa#/inbox",
+			want:          "https://example.com/spa#/inbox",
+		},
+		{
+			name: "canonicalize should follow custom rules",
+			canonicalizer: bookmark.Canonicalizer{
+				RemoveParams:   []string{"ref"},
+				RemoveFragment: true,
+			},
+			url:  "https://example.com/docs/?utm_source=x&ref=home&b=1&a=2#intro",
+			want: "https://example.com/docs/?utm_source=x&b=1&a=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.canonicalizer.Canonicalize(tt.url)
+			if err != nil {
+				t.Fatalf("Canonicalizer.Canonicalize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Canonicalizer.Canonicalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "HTTPS://Example.COM:443/Docs/", want: "https://example.com/Docs/"},
+		{url: "http://example.com:80/?b=2&a=1&utm_source=x#top", want: "http://example.com/?b=2&a=1&utm_source=x#top"},
+		{url: "https://example.com:8443", want: "https://example.com:8443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := bookmark.NormalizeURL(tt.url)
+			if err != nil {
+				t.Fatalf("NormalizeURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizer_Key(t *testing.T) {
+	c := bookmark.DefaultCanonicalizer()
+	urls := []string{
+		"https://example.com/",
+		"http://example.com",
+		"https://example.com/?utm_source=x",
+		"HTTPS://EXAMPLE.COM:443",
+	}
+	for _, u := range urls {
+		if got, want := c.Key(u), c.Key(urls[0]); got != want {
+			t.Errorf("Canonicalizer.Key(%q) = %q, want %q", u, got, want)
+		}
+	}
+	c.IgnoreScheme = false
+	if c.Key("http://example.com") == c.Key("https://example.com") {
+		t.Errorf("Canonicalizer.Key() should tell the schemes apart when they are not ignored")
+	}
+}
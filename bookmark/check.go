@@ -0,0 +1,167 @@
+package bookmark
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCheckConcurrency is the number of links that Check checks at once
+// when no concurrency is given.
+const DefaultCheckConcurrency = 8
+
+// Health is the result of checking the link of a bookmark.
+type Health struct {
+	// StatusCode is the HTTP status of the last check. It is zero when the
+	// request failed.
+	StatusCode int
+	// Error is why the request of the last check failed.
+	Error string
+	// FinalURL is the URL after following redirects.
+	FinalURL string
+	// PermanentRedirect is set when the link redirects to FinalURL with
+	// permanent redirects only.
+	PermanentRedirect bool
+	// Failures is the number of consecutive failed checks.
+	Failures  int
+	CheckedAt time.Time
+}
+
+// Broken reports whether the check failed.
+func (h Health) Broken() bool {
+	return h.Error != "" || h.StatusCode >= http.StatusBadRequest
+}
+
+// Redirected reports whether the link of the bookmark redirected to another
+// URL when it was last checked.
+func (b *Bookmark) Redirected() bool {
+	return b.Health.FinalURL != "" && b.Health.FinalURL != b.Content
+}
+
+// CheckOptions configure Check.
+type CheckOptions struct {
+	// Concurrency is the number of links checked at once. Zero uses
+	// DefaultCheckConcurrency.
+	Concurrency int
+	// FixRedirects replaces the URL of bookmarks that permanently redirect
+	// by the target of the redirect.
+	FixRedirects bool
+	// Progress is called after every bookmark with the number of bookmarks
+	// that are done. Calls are never concurrent.
+	Progress func(done, total int)
+}
+
+// CheckResult is the outcome of checking the link of a bookmark.
+type CheckResult struct {
+	// Bookmark is the bookmark before the check.
+	Bookmark *Bookmark
+	// Updated is the bookmark with the result of the check. It is nil when
+	// Err is set.
+	Updated *Bookmark
+	Err     error
+}
+
+// Fixed reports whether the URL was replaced by its permanent redirect.
+func (r CheckResult) Fixed() bool {
+	return r.Err == nil && r.Updated.Content != r.Bookmark.Content
+}
+
+// Check checks the links of the bookmarks and stores their health. Links are
+// requested with HEAD, falling back to GET for servers that do not support
+// HEAD. Err of a result is only set when the bookmark could not be checked
+// or stored, a broken link is reported in its health. The results are in
+// the order of the bookmarks.
+func (l *Library) Check(ctx context.Context, bookmarks []*Bookmark, opts CheckOptions) []CheckResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCheckConcurrency
+	}
+	results := make([]CheckResult, len(bookmarks))
+	var mu sync.Mutex
+	var done int
+	forEach(len(bookmarks), concurrency, func(i int) {
+		b := bookmarks[i]
+		updated, err := l.check(ctx, b, opts.FixRedirects)
+		if err != nil {
+			l.logger.DebugContext(ctx, "could not check link", slog.String("id", b.ID), slog.Any("error", err))
+		}
+		results[i] = CheckResult{Bookmark: b, Updated: updated, Err: err}
+		if opts.Progress != nil {
+			mu.Lock()
+			done++
+			opts.Progress(done, len(bookmarks))
+			mu.Unlock()
+		}
+	})
+	return results
+}
+
+// check checks and stores the health of a single bookmark.
+func (l *Library) check(ctx context.Context, b *Bookmark, fixRedirects bool) (*Bookmark, error) {
+	if !b.HasURL() {
+		return nil, fmt.Errorf("content of %s is not a url", b.ID)
+	}
+	h := l.checkLink(ctx, b.Content, http.MethodHead)
+	if h.Broken() {
+		h = l.checkLink(ctx, b.Content, http.MethodGet)
+	}
+	if ctx.Err() != nil {
+		// a cancelled check says nothing about the link
+		return nil, ctx.Err()
+	}
+	h.CheckedAt = l.now()
+	if h.Broken() {
+		h.Failures = b.Health.Failures + 1
+	}
+	p := &Patch{Health: &h}
+	if fixRedirects && h.PermanentRedirect && !h.Broken() {
+		p.Content = &h.FinalURL
+	}
+	return l.Update(b.ID, p)
+}
+
+// checkLink requests the URL with the given method and returns its health
+// without the check time and failure count.
+func (l *Library) checkLink(ctx context.Context, url, method string) Health {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return Health{Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	permanent := true
+	client := *l.client
+	if t, ok := client.Transport.(*policyTransport); ok {
+		// the body is never read, so links to large files are not broken
+		unlimited := *t
+		unlimited.maxBody = 0
+		client.Transport = &unlimited
+	}
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		switch req.Response.StatusCode {
+		case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		default:
+			permanent = false
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= DefaultMaxRedirects {
+			return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, DefaultMaxRedirects)
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Health{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	h := Health{StatusCode: resp.StatusCode, FinalURL: resp.Request.URL.String()}
+	h.PermanentRedirect = permanent && h.FinalURL != url
+	return h
+}
@@ -0,0 +1,188 @@
+package bookmark_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLibrary_Check(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.Handle("/moved", http.RedirectHandler("/hop", http.StatusMovedPermanently))
+	mux.Handle("/hop", http.RedirectHandler("/ok", http.StatusPermanentRedirect))
+	mux.Handle("/temporary", http.RedirectHandler("/ok", http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	newLibrary := func(t *testing.T) (*bookmark.Library, []*bookmark.Bookmark) {
+		t.Helper()
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		for _, p := range []string{"/ok", "/gone", "/no-head", "/moved", "/temporary"} {
+			b := &bookmark.Bookmark{Title: p, Content: server.URL + p}
+			if p == "/gone" {
+				b.Health = bookmark.Health{StatusCode: http.StatusNotFound, Failures: 2}
+			}
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store, bookmark.WithClock(func() time.Time { return now }))
+		bookmarks, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		return lib, bookmarks
+	}
+
+	t.Run("check should store the health of every link", func(t *testing.T) {
+		lib, bookmarks := newLibrary(t)
+		results := lib.Check(t.Context(), bookmarks, bookmark.CheckOptions{})
+		var got []bookmark.Health
+		for _, r := range results {
+			if r.Err != nil {
+				t.Fatalf("Library.Check() error = %v", r.Err)
+			}
+			stored, err := lib.Get(r.Bookmark.ID)
+			if err != nil {
+				t.Fatalf("Library.Get() error = %v", err)
+			}
+			got = append(got, stored.Health)
+		}
+		want := []bookmark.Health{
+			{StatusCode: http.StatusOK, FinalURL: server.URL + "/ok", CheckedAt: now},
+			{StatusCode: http.StatusNotFound, FinalURL: server.URL + "/gone", Failures: 3, CheckedAt: now},
+			{StatusCode: http.StatusOK, FinalURL: server.URL + "/no-head", CheckedAt: now},
+			{StatusCode: http.StatusOK, FinalURL: server.URL + "/ok", PermanentRedirect: true, CheckedAt: now},
+			{StatusCode: http.StatusOK, FinalURL: server.URL + "/ok", CheckedAt: now},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.Check() health mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("check should reset the failures of working links", func(t *testing.T) {
+		lib, bookmarks := newLibrary(t)
+		if _, err := lib.Update(bookmarks[0].ID, &bookmark.Patch{Health: &bookmark.Health{Failures: 4}}); err != nil {
+			t.Fatalf("Library.Update() error = %v", err)
+		}
+		results := lib.Check(t.Context(), bookmarks[:1], bookmark.CheckOptions{})
+		if f := results[0].Updated.Health.Failures; f != 0 {
+			t.Errorf("Failures = %d, want 0", f)
+		}
+	})
+
+	t.Run("check should record why a request failed", func(t *testing.T) {
+		lib, _ := newLibrary(t)
+		b := &bookmark.Bookmark{Title: "Closed", Content: "http://127.0.0.1:1/"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		results := lib.Check(t.Context(), []*bookmark.Bookmark{b}, bookmark.CheckOptions{})
+		h := results[0].Updated.Health
+		if !h.Broken() || h.Error == "" || h.Failures != 1 {
+			t.Errorf("Health = %+v, want a broken link with an error", h)
+		}
+	})
+
+	t.Run("check should not limit the size of pages", func(t *testing.T) {
+		const size = 10_000_000
+		large := func(head bool) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead && !head {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(size))
+				if r.Method == http.MethodGet {
+					_, _ = w.Write(make([]byte, size))
+				}
+			}
+		}
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{name: "head", handler: large(true)},
+			{name: "get", handler: large(false)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+				lib := bookmark.NewLibrary(
+					slog.New(slog.DiscardHandler),
+					json.NewStore(path.Join(t.TempDir(), "test.json")),
+					bookmark.WithHTTPClient(bookmark.NewHTTPClient(bookmark.DefaultFetchPolicy())),
+					bookmark.WithOffline(),
+				)
+				b := &bookmark.Bookmark{Title: "Large", Content: server.URL + "/large.iso"}
+				if err := lib.Add(t.Context(), b); err != nil {
+					t.Fatalf("Library.Add() error = %v", err)
+				}
+				results := lib.Check(t.Context(), []*bookmark.Bookmark{b}, bookmark.CheckOptions{})
+				if r := results[0]; r.Err != nil || r.Updated.Health.Broken() {
+					t.Errorf("Library.Check() = %+v, want a working link", r)
+				}
+			})
+		}
+	})
+
+	t.Run("fix redirects should only replace permanent redirects", func(t *testing.T) {
+		lib, bookmarks := newLibrary(t)
+		results := lib.Check(t.Context(), bookmarks, bookmark.CheckOptions{FixRedirects: true})
+		var fixed []string
+		for _, r := range results {
+			if r.Fixed() {
+				fixed = append(fixed, r.Bookmark.Title+" "+r.Updated.Content)
+			}
+		}
+		if diff := cmp.Diff([]string{"/moved " + server.URL + "/ok"}, fixed); diff != "" {
+			t.Errorf("fixed bookmarks mismatch (-want +got):\n%s", diff)
+		}
+		stored, err := lib.Get(bookmarks[3].ID)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		if stored.Content != server.URL+"/ok" || stored.Redirected() {
+			t.Errorf("stored bookmark = %+v, want the redirect target", stored)
+		}
+	})
+
+	t.Run("check should not store anything for cancelled checks", func(t *testing.T) {
+		lib, bookmarks := newLibrary(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		for _, r := range lib.Check(ctx, bookmarks, bookmark.CheckOptions{}) {
+			if r.Err == nil {
+				t.Errorf("Library.Check() of %s should fail", r.Bookmark.Title)
+			}
+		}
+		stored, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		if diff := cmp.Diff(bookmarks, stored, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("stored bookmarks changed (-want +got):\n%s", diff)
+		}
+	})
+}
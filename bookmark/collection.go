@@ -0,0 +1,339 @@
+package bookmark
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CollectionSeparator separates the names in the path of a collection.
+const CollectionSeparator = "/"
+
+var (
+	// ErrCollectionNotFound is returned when a collection is not found.
+	ErrCollectionNotFound = errors.New("collection not found")
+	// ErrCollectionExists is returned when a collection is moved to a path
+	// that is already taken.
+	ErrCollectionExists = errors.New("collection already exists")
+	// ErrCollectionNotEmpty is returned when removing a collection that
+	// still holds bookmarks or other collections.
+	ErrCollectionNotEmpty = errors.New("collection is not empty")
+	// ErrNoCollectionStore is returned when creating collections in a store
+	// that cannot keep them.
+	ErrNoCollectionStore = errors.New("store does not support collections")
+)
+
+// Collection is a folder of bookmarks. Collections are identified by their
+// path, the names of the collection and its parents separated by
+// CollectionSeparator, like "dev/go". The empty path is the root, which
+// holds the bookmarks that are not in a collection.
+//
+// Collections exist while bookmarks are in them. Stores that implement
+// CollectionStore also keep empty collections.
+type Collection struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// CollectionStore is implemented by stores that keep empty collections.
+type CollectionStore interface {
+	// AddCollection adds a collection. Adding an existing collection keeps
+	// the existing one.
+	AddCollection(c *Collection) error
+	// Collections returns the collections, sorted by path.
+	Collections() ([]*Collection, error)
+	// DeleteCollection deletes the collection with the given path. It
+	// returns ErrCollectionNotFound when there is none.
+	DeleteCollection(path string) error
+}
+
+// collectionEscaper and collectionUnescaper escape the names in collection
+// paths.
+//
+//nolint:gochecknoglobals // compiled once
+var (
+	collectionEscaper   = strings.NewReplacer(`\`, `\\`, CollectionSeparator, `\`+CollectionSeparator)
+	collectionUnescaper = strings.NewReplacer(`\\`, `\`, `\`+CollectionSeparator, CollectionSeparator)
+)
+
+// EscapeCollectionName escapes a name for the path of a collection, so a
+// name with a CollectionSeparator in it, like the browser folder "A/B",
+// stays a single name: "A\/B".
+func EscapeCollectionName(name string) string {
+	return collectionEscaper.Replace(name)
+}
+
+// UnescapeCollectionName returns the name that EscapeCollectionName
+// escaped. Other backslashes are kept.
+func UnescapeCollectionName(name string) string {
+	return collectionUnescaper.Replace(name)
+}
+
+// splitPath splits a collection path at the separators that are not
+// escaped.
+func splitPath(path string) []string {
+	var names []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\':
+			i++
+		case strings.HasPrefix(path[i:], CollectionSeparator):
+			names = append(names, path[start:i])
+			start = i + len(CollectionSeparator)
+		}
+	}
+	return append(names, path[start:])
+}
+
+// NormalizeCollection returns the canonical path of a collection. Names are
+// trimmed and empty names are dropped, so " dev//go/ " becomes "dev/go".
+// Escaped separators are kept in their name.
+func NormalizeCollection(path string) string {
+	var names []string
+	for _, name := range splitPath(path) {
+		if name = strings.TrimSpace(UnescapeCollectionName(name)); name != "" {
+			names = append(names, EscapeCollectionName(name))
+		}
+	}
+	return strings.Join(names, CollectionSeparator)
+}
+
+// JoinCollection returns the path of the collection with the given names,
+// outermost first. Names are escaped like EscapeCollectionName does.
+func JoinCollection(names ...string) string {
+	return NormalizeCollection(strings.Join(names, CollectionSeparator))
+}
+
+// SplitCollection returns the names in the path of a collection, outermost
+// first, escaped like EscapeCollectionName does. The root has no names.
+func SplitCollection(path string) []string {
+	if path = NormalizeCollection(path); path == "" {
+		return nil
+	}
+	return splitPath(path)
+}
+
+// CompareCollections compares collection paths name by name, so
+// collections sort right before the collections inside them.
+func CompareCollections(a, b string) int {
+	return slices.Compare(SplitCollection(a), SplitCollection(b))
+}
+
+// within reports whether the collection path is the given collection or
+// inside it. Every collection is within the root.
+func within(path, collection string) bool {
+	return collection == "" || path == collection || strings.HasPrefix(path, collection+CollectionSeparator)
+}
+
+// InCollection reports whether the bookmark is in the collection or in one
+// of the collections inside it.
+func (b *Bookmark) InCollection(collection string) bool {
+	return within(b.Collection, NormalizeCollection(collection))
+}
+
+// collectionStore returns the store as a CollectionStore.
+func (l *Library) collectionStore() (CollectionStore, error) {
+	s, ok := l.store.(CollectionStore)
+	if !ok {
+		return nil, ErrNoCollectionStore
+	}
+	return s, nil
+}
+
+// Collections returns the paths of all collections, sorted with
+// CompareCollections. These are the collections of the store, the
+// collections that bookmarks are in and the collections around them.
+func (l *Library) Collections() ([]string, error) {
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, b := range bookmarks {
+		paths = append(paths, b.Collection)
+	}
+	if s, ok := l.store.(CollectionStore); ok {
+		collections, cErr := s.Collections()
+		if cErr != nil {
+			return nil, cErr
+		}
+		for _, c := range collections {
+			paths = append(paths, c.Path)
+		}
+	}
+	seen := map[string]bool{"": true}
+	var result []string
+	for _, path := range paths {
+		names := SplitCollection(path)
+		for i := range names {
+			if p := JoinCollection(names[:i+1]...); !seen[p] {
+				seen[p] = true
+				result = append(result, p)
+			}
+		}
+	}
+	slices.SortFunc(result, CompareCollections)
+	return result, nil
+}
+
+// HasCollection reports whether the collection exists. The root always
+// exists.
+func (l *Library) HasCollection(path string) (bool, error) {
+	path = NormalizeCollection(path)
+	if path == "" {
+		return true, nil
+	}
+	collections, err := l.Collections()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(collections, path), nil
+}
+
+// MakeCollection creates an empty collection and returns its path. The
+// collections around it are created as well. Making a collection that
+// exists does nothing.
+func (l *Library) MakeCollection(path string) (string, error) {
+	store, err := l.collectionStore()
+	if err != nil {
+		return "", err
+	}
+	if path = NormalizeCollection(path); path == "" {
+		return "", errors.New("path of collection is empty")
+	}
+	if err = l.addCollections(store, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// addCollections adds the collection and the collections around it to the
+// store.
+func (l *Library) addCollections(store CollectionStore, path string) error {
+	names := SplitCollection(path)
+	for i := range names {
+		if err := store.AddCollection(&Collection{Path: JoinCollection(names[:i+1]...), CreatedAt: l.now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepCollection adds the collection of a bookmark to stores that keep
+// collections, so it stays when the bookmarks are moved out of it.
+func (l *Library) keepCollection(path string) error {
+	store, ok := l.store.(CollectionStore)
+	if !ok {
+		return nil
+	}
+	return l.addCollections(store, path)
+}
+
+// Move moves the bookmark with the given ID to a collection. The empty
+// path moves it out of all collections.
+func (l *Library) Move(id, collection string) (*Bookmark, error) {
+	collection = NormalizeCollection(collection)
+	return l.Update(id, &Patch{Collection: &collection})
+}
+
+// MoveCollection moves a collection with everything in it and returns its
+// new path. Like mv, a collection that is moved to an existing collection
+// is moved into it, otherwise it is renamed.
+func (l *Library) MoveCollection(from, to string) (string, error) {
+	from, to = NormalizeCollection(from), NormalizeCollection(to)
+	collections, err := l.Collections()
+	if err != nil {
+		return "", err
+	}
+	if from == "" || !slices.Contains(collections, from) {
+		return "", fmt.Errorf("%w: %s", ErrCollectionNotFound, from)
+	}
+	if to == "" || slices.Contains(collections, to) {
+		names := SplitCollection(from)
+		to = JoinCollection(to, names[len(names)-1])
+	}
+	if within(to, from) {
+		return "", fmt.Errorf("cannot move collection %s into itself", from)
+	}
+	if slices.Contains(collections, to) {
+		return "", fmt.Errorf("%w: %s", ErrCollectionExists, to)
+	}
+	// rename the stored collections first, so a failure leaves the
+	// bookmarks where they were
+	if store, ok := l.store.(CollectionStore); ok {
+		if err = moveStoredCollections(store, from, to); err != nil {
+			return "", err
+		}
+		if err = l.addCollections(store, to); err != nil {
+			return "", err
+		}
+	}
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return "", err
+	}
+	for _, b := range bookmarks {
+		if within(b.Collection, from) {
+			if _, err = l.Move(b.ID, to+strings.TrimPrefix(b.Collection, from)); err != nil {
+				return "", fmt.Errorf("could not move bookmark %s: %w", b.ID, err)
+			}
+		}
+	}
+	return to, nil
+}
+
+// moveStoredCollections renames the stored collections within from.
+func moveStoredCollections(store CollectionStore, from, to string) error {
+	collections, err := store.Collections()
+	if err != nil {
+		return err
+	}
+	for _, c := range collections {
+		if !within(c.Path, from) {
+			continue
+		}
+		moved := &Collection{Path: to + strings.TrimPrefix(c.Path, from), CreatedAt: c.CreatedAt}
+		if err = store.AddCollection(moved); err != nil {
+			return err
+		}
+		if err = store.DeleteCollection(c.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveCollection removes an empty collection. It returns
+// ErrCollectionNotEmpty when bookmarks or other collections are in it.
+func (l *Library) RemoveCollection(path string) error {
+	store, err := l.collectionStore()
+	if err != nil {
+		return err
+	}
+	path = NormalizeCollection(path)
+	collections, err := l.Collections()
+	if err != nil {
+		return err
+	}
+	if path == "" || !slices.Contains(collections, path) {
+		return fmt.Errorf("%w: %s", ErrCollectionNotFound, path)
+	}
+	for _, c := range collections {
+		if c != path && within(c, path) {
+			return fmt.Errorf("%w: %s holds collection %s", ErrCollectionNotEmpty, path, c)
+		}
+	}
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if b.Collection == path {
+			return fmt.Errorf("%w: %s holds bookmark %s", ErrCollectionNotEmpty, path, b.ID)
+		}
+	}
+	return store.DeleteCollection(path)
+}
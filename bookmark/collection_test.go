@@ -0,0 +1,209 @@
+package bookmark_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/export"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeCollection(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "/", want: ""},
+		{path: "dev/go", want: "dev/go"},
+		{path: " dev//Go Tools/ ", want: "dev/Go Tools"},
+		{path: `A\/B/go`, want: `A\/B/go`},
+		{path: `A\/ /B`, want: `A\//B`},
+		{path: `C:\dev\`, want: `C:\\dev\\`},
+	}
+	for _, tt := range tests {
+		if got := bookmark.NormalizeCollection(tt.path); got != tt.want {
+			t.Errorf("NormalizeCollection(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCollection(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "", want: nil},
+		{path: "dev/go", want: []string{"dev", "go"}},
+		{path: `A\/B/go`, want: []string{"A/B", "go"}},
+		{path: bookmark.JoinCollection(bookmark.EscapeCollectionName(`C:\dev\`), "go"), want: []string{`C:\dev\`, "go"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, name := range bookmark.SplitCollection(tt.path) {
+			got = append(got, bookmark.UnescapeCollectionName(name))
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("SplitCollection(%q) mismatch (-want +got):\n%s", tt.path, diff)
+		}
+	}
+}
+
+func TestLibrary_Collections(t *testing.T) {
+	newLibrary := func(t *testing.T) *bookmark.Library {
+		t.Helper()
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+		for _, b := range []*bookmark.Bookmark{
+			{ID: "1", Title: "Go blog", Content: "https://go.dev/blog", Collection: "dev/go"},
+			{ID: "2", Title: "Go tools", Content: "https://go.dev/tools", Collection: "dev-tools"},
+			{ID: "3", Title: "News", Content: "https://news.ycombinator.com"},
+		} {
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		if _, err := lib.MakeCollection("reading/later"); err != nil {
+			t.Fatalf("Library.MakeCollection() error = %v", err)
+		}
+		return lib
+	}
+	collectionOf := func(t *testing.T, lib *bookmark.Library, id string) string {
+		t.Helper()
+		b, err := lib.Get(id)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		return b.Collection
+	}
+
+	t.Run("collections should include the collections around them", func(t *testing.T) {
+		got, err := newLibrary(t).Collections()
+		if err != nil {
+			t.Fatalf("Library.Collections() error = %v", err)
+		}
+		want := []string{"dev", "dev/go", "dev-tools", "reading", "reading/later"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.Collections() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("move should keep the collection the bookmark leaves", func(t *testing.T) {
+		lib := newLibrary(t)
+		if _, err := lib.Move("2", "/reading/"); err != nil {
+			t.Fatalf("Library.Move() error = %v", err)
+		}
+		if got := collectionOf(t, lib, "2"); got != "reading" {
+			t.Errorf("Library.Move() collection = %q, want %q", got, "reading")
+		}
+		if ok, err := lib.HasCollection("dev-tools"); err != nil || ok {
+			t.Errorf("Library.HasCollection() = %v, %v, want false for a collection that was never kept", ok, err)
+		}
+		if _, err := lib.Move("2", "dev-tools"); err != nil {
+			t.Fatalf("Library.Move() error = %v", err)
+		}
+		if _, err := lib.Move("2", ""); err != nil {
+			t.Fatalf("Library.Move() error = %v", err)
+		}
+		if ok, err := lib.HasCollection("dev-tools"); err != nil || !ok {
+			t.Errorf("Library.HasCollection() = %v, %v, want true", ok, err)
+		}
+	})
+
+	t.Run("move collection should rename a collection with everything in it", func(t *testing.T) {
+		lib := newLibrary(t)
+		to, err := lib.MoveCollection("dev", "code")
+		if err != nil {
+			t.Fatalf("Library.MoveCollection() error = %v", err)
+		}
+		if to != "code" || collectionOf(t, lib, "1") != "code/go" {
+			t.Errorf("Library.MoveCollection() = %q, bookmark in %q, want code and code/go", to, collectionOf(t, lib, "1"))
+		}
+	})
+
+	t.Run("move collection should move into an existing collection", func(t *testing.T) {
+		lib := newLibrary(t)
+		if _, err := lib.MoveCollection("reading", "dev/go"); err != nil {
+			t.Fatalf("Library.MoveCollection() error = %v", err)
+		}
+		got, err := lib.Collections()
+		if err != nil {
+			t.Fatalf("Library.Collections() error = %v", err)
+		}
+		want := []string{"dev", "dev/go", "dev/go/reading", "dev/go/reading/later", "dev-tools"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.Collections() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("move collection should not move a collection into itself", func(t *testing.T) {
+		lib := newLibrary(t)
+		if _, err := lib.MoveCollection("dev", "dev/go"); err == nil {
+			t.Error("Library.MoveCollection() error = nil, want an error")
+		}
+		if _, err := lib.MoveCollection("missing", "dev"); !errors.Is(err, bookmark.ErrCollectionNotFound) {
+			t.Errorf("Library.MoveCollection() error = %v, want %v", err, bookmark.ErrCollectionNotFound)
+		}
+		if _, err := lib.MakeCollection("dev/later"); err != nil {
+			t.Fatalf("Library.MakeCollection() error = %v", err)
+		}
+		if _, err := lib.MoveCollection("reading/later", "dev"); !errors.Is(err, bookmark.ErrCollectionExists) {
+			t.Errorf("Library.MoveCollection() error = %v, want %v", err, bookmark.ErrCollectionExists)
+		}
+	})
+
+	t.Run("remove collection should only remove empty collections", func(t *testing.T) {
+		lib := newLibrary(t)
+		for _, path := range []string{"reading", "dev/go"} {
+			if err := lib.RemoveCollection(path); !errors.Is(err, bookmark.ErrCollectionNotEmpty) {
+				t.Errorf("Library.RemoveCollection(%q) error = %v, want %v", path, err, bookmark.ErrCollectionNotEmpty)
+			}
+		}
+		for _, path := range []string{"reading/later", "reading"} {
+			if err := lib.RemoveCollection(path); err != nil {
+				t.Errorf("Library.RemoveCollection(%q) error = %v", path, err)
+			}
+		}
+		if err := lib.RemoveCollection("reading"); !errors.Is(err, bookmark.ErrCollectionNotFound) {
+			t.Errorf("Library.RemoveCollection() error = %v, want %v", err, bookmark.ErrCollectionNotFound)
+		}
+	})
+
+	t.Run("netscape export and import should keep collections", func(t *testing.T) {
+		bookmarks, err := newLibrary(t).List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		bookmarks = append(bookmarks, &bookmark.Bookmark{Title: "Slash", Content: "https://a.b", Collection: `A\/B/c`})
+		var file bytes.Buffer
+		if err = export.Netscape(&file, bookmarks); err != nil {
+			t.Fatalf("export.Netscape() error = %v", err)
+		}
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), json.NewStore(path.Join(t.TempDir(), "test.json")))
+		if _, err = lib.ImportNetscape(&file, bookmark.ImportOptions{}); err != nil {
+			t.Fatalf("Library.ImportNetscape() error = %v", err)
+		}
+		imported, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		got := map[string]string{}
+		for _, b := range imported {
+			got[b.Content] = b.Collection
+		}
+		want := map[string]string{
+			"https://go.dev/blog":          "dev/go",
+			"https://go.dev/tools":         "dev-tools",
+			"https://news.ycombinator.com": "",
+			"https://a.b":                  `A\/B/c`,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.ImportNetscape() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+// Package export writes bookmarks in formats that other tools can read.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/netscape"
+)
+
+// Writer writes bookmarks in an export format.
+type Writer interface {
+	Write(w io.Writer, bookmarks []*bookmark.Bookmark) error
+}
+
+// WriterFunc is a function that implements Writer.
+type WriterFunc func(w io.Writer, bookmarks []*bookmark.Bookmark) error
+
+// Write implements Writer.
+func (f WriterFunc) Write(w io.Writer, bookmarks []*bookmark.Bookmark) error {
+	return f(w, bookmarks)
+}
+
+// Names of the built-in formats.
+const (
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// Formats returns the built-in writers by format name.
+func Formats() map[string]Writer {
+	return map[string]Writer{
+		FormatHTML:     WriterFunc(Netscape),
+		FormatJSON:     WriterFunc(JSON),
+		FormatCSV:      WriterFunc(CSV),
+		FormatMarkdown: WriterFunc(Markdown),
+	}
+}
+
+// FormatNames returns the sorted names of the built-in formats.
+func FormatNames() []string {
+	var names []string
+	for name := range Formats() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Netscape writes the bookmarks as a Netscape bookmark file that browsers
+// can import. Collections become folders and tags are written to the TAGS
+// attribute.
+func Netscape(w io.Writer, bookmarks []*bookmark.Bookmark) error {
+	entries := make([]*netscape.Entry, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		var folders []string
+		for _, name := range bookmark.SplitCollection(b.Collection) {
+			folders = append(folders, bookmark.UnescapeCollectionName(name))
+		}
+		entries = append(entries, &netscape.Entry{
+			Title:   b.Title,
+			URL:     b.Content,
+			AddDate: b.CreatedAt,
+			Folders: folders,
+			Tags:    b.Tags,
+		})
+	}
+	return netscape.Write(w, entries)
+}
+
+// jsonBookmark is the JSON representation of an exported bookmark.
+type jsonBookmark struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+	// Collection is only written for bookmarks in a collection.
+	Collection string    `json:"collection,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`
+}
+
+// JSON writes the bookmarks as a JSON array.
+func JSON(w io.Writer, bookmarks []*bookmark.Bookmark) error {
+	out := make([]jsonBookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		tags := b.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		out = append(out, jsonBookmark{
+			ID:         b.ID,
+			Title:      b.Title,
+			Content:    b.Content,
+			Tags:       tags,
+			Collection: b.Collection,
+			CreatedAt:  b.CreatedAt,
+			UpdatedAt:  b.UpdatedAt,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// CSV writes the bookmarks as CSV with a header row. Tags are separated by
+// spaces.
+func CSV(w io.Writer, bookmarks []*bookmark.Bookmark) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "title", "content", "tags", "created_at", "updated_at"}); err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if err := cw.Write([]string{
+			b.ID,
+			b.Title,
+			b.Content,
+			strings.Join(b.Tags, " "),
+			formatTime(b.CreatedAt),
+			formatTime(b.UpdatedAt),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// untagged is the heading of bookmarks without tags in Markdown exports.
+const untagged = "Untagged"
+
+// Markdown writes the bookmarks as a Markdown list of links grouped by tag.
+// Bookmarks with multiple tags are listed under every tag.
+func Markdown(w io.Writer, bookmarks []*bookmark.Bookmark) error {
+	groups := map[string][]*bookmark.Bookmark{}
+	for _, b := range bookmarks {
+		if len(b.Tags) == 0 {
+			groups[untagged] = append(groups[untagged], b)
+		}
+		for _, t := range b.Tags {
+			groups[t] = append(groups[t], b)
+		}
+	}
+	tags := make([]string, 0, len(groups))
+	for t := range groups {
+		if t != untagged {
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	if _, ok := groups[untagged]; ok {
+		tags = append(tags, untagged)
+	}
+	var sb strings.Builder
+	sb.WriteString("# Bookmarks\n")
+	for _, t := range tags {
+		fmt.Fprintf(&sb, "\n## %s\n\n", t)
+		for _, b := range groups[t] {
+			fmt.Fprintf(&sb, "- [%s](%s)\n", markdownEscaper.Replace(b.Title), markdownDestination(b.Content))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// markdownEscaper escapes the characters that would end link text.
+//
+//nolint:gochecknoglobals // a replacer is safe for concurrent use
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+// markdownDestination returns the link destination for a URL. URLs with
+// spaces or parentheses are wrapped in angle brackets.
+func markdownDestination(url string) string {
+	if strings.ContainsAny(url, " ()") {
+		return "<" + url + ">"
+	}
+	return url
+}
+
+// formatTime formats a time as RFC 3339, the zero time as empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
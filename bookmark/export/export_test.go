@@ -0,0 +1,124 @@
+package export_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/export"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testBookmarks() []*bookmark.Bookmark {
+	return []*bookmark.Bookmark{
+		{
+			ID:         "01JQ0000000000000000000001",
+			Title:      "The Go Programming Language",
+			Content:    "https://go.dev/",
+			Tags:       []string{"go", "lang"},
+			Collection: "dev/go",
+			CreatedAt:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:        "01JQ0000000000000000000002",
+			Title:     "Example [site]",
+			Content:   "https://example.com/",
+			CreatedAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: export.FormatJSON,
+			want: `[
+  {
+    "id": "01JQ0000000000000000000001",
+    "title": "The Go Programming Language",
+    "content": "https://go.dev/",
+    "tags": [
+      "go",
+      "lang"
+    ],
+    "collection": "dev/go",
+    "created_at": "2021-01-01T00:00:00Z"
+  },
+  {
+    "id": "01JQ0000000000000000000002",
+    "title": "Example [site]",
+    "content": "https://example.com/",
+    "tags": [],
+    "created_at": "2021-01-02T00:00:00Z",
+    "updated_at": "2021-01-03T00:00:00Z"
+  }
+]
+`,
+		},
+		{
+			format: export.FormatCSV,
+			want: `id,title,content,tags,created_at,updated_at
+01JQ0000000000000000000001,The Go Programming Language,https://go.dev/,go lang,2021-01-01T00:00:00Z,
+01JQ0000000000000000000002,Example [site],https://example.com/,,2021-01-02T00:00:00Z,2021-01-03T00:00:00Z
+`,
+		},
+		{
+			format: export.FormatMarkdown,
+			want: `# Bookmarks
+
+## go
+
+- [The Go Programming Language](https://go.dev/)
+
+## lang
+
+- [The Go Programming Language](https://go.dev/)
+
+## Untagged
+
+- [Example \[site\]](https://example.com/)
+`,
+		},
+		{
+			format: export.FormatHTML,
+			want: `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://example.com/" ADD_DATE="1609545600">Example [site]</A>
+    <DT><H3>dev</H3>
+    <DL><p>
+        <DT><H3>go</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/" ADD_DATE="1609459200" TAGS="go,lang">The Go Programming Language</A>
+        </DL><p>
+    </DL><p>
+</DL><p>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w, ok := export.Formats()[tt.format]
+			if !ok {
+				t.Fatalf("format %q not found", tt.format)
+			}
+			var b strings.Builder
+			if err := w.Write(&b, testBookmarks()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
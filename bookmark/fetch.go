@@ -0,0 +1,222 @@
+package bookmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBodyTooLarge is returned when a page is larger than the fetch
+	// policy allows.
+	ErrBodyTooLarge = errors.New("page too large")
+	// ErrTooManyRedirects is returned when a page redirects more often than
+	// the fetch policy allows.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrSchemeNotAllowed is returned when a URL has a scheme that the fetch
+	// policy does not allow.
+	ErrSchemeNotAllowed = errors.New("scheme not allowed")
+	// ErrAddressNotAllowed is returned when a host resolves to a private,
+	// loopback or link-local address and the fetch policy denies those.
+	ErrAddressNotAllowed = errors.New("address not allowed")
+)
+
+// Fetcher fetches the metadata of the page at a URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*Metadata, error)
+}
+
+// FetcherFunc is a function that implements Fetcher.
+type FetcherFunc func(ctx context.Context, url string) (*Metadata, error)
+
+// Fetch calls f(ctx, url).
+func (f FetcherFunc) Fetch(ctx context.Context, url string) (*Metadata, error) {
+	return f(ctx, url)
+}
+
+// HTTPFetcher fetches metadata over HTTP with FetchMetadata.
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// Fetch fetches the metadata of the page at url.
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*Metadata, error) {
+	return FetchMetadata(ctx, f.Client, url)
+}
+
+// UserAgent is sent with every request, when fetching metadata, checking
+// links and archiving pages.
+const UserAgent = "bookmarks"
+
+// Defaults of the fetch policy.
+const (
+	DefaultFetchTimeout = 10 * time.Second
+	DefaultMaxBodyBytes = 5 << 20 // 5 MiB
+	DefaultMaxRedirects = 10
+)
+
+// FetchPolicy limits how pages are fetched.
+type FetchPolicy struct {
+	// Timeout is the time limit of a request, including redirects and
+	// reading the body. Zero means no timeout.
+	Timeout time.Duration
+	// MaxBodyBytes is the largest page that is read. Zero means no limit.
+	MaxBodyBytes int64
+	// MaxRedirects is the number of redirects that are followed.
+	MaxRedirects int
+	// AllowedSchemes are the URL schemes that may be fetched, including
+	// the targets of redirects. Empty allows any scheme the client supports.
+	AllowedSchemes []string
+	// DenyPrivateNetworks refuses connections to addresses that are not
+	// publicly routable, like private, shared, loopback, link-local and
+	// multicast addresses, which include the metadata endpoints of clouds.
+	// The check is done on the resolved address, so it also covers host
+	// names pointing to them.
+	// Proxies from the environment are not used when it is set.
+	DenyPrivateNetworks bool
+}
+
+// DefaultFetchPolicy returns the policy used when none is configured. It only
+// allows http and https.
+func DefaultFetchPolicy() FetchPolicy {
+	return FetchPolicy{
+		Timeout:        DefaultFetchTimeout,
+		MaxBodyBytes:   DefaultMaxBodyBytes,
+		MaxRedirects:   DefaultMaxRedirects,
+		AllowedSchemes: []string{"http", "https"},
+	}
+}
+
+// NewHTTPClient returns an HTTP client that enforces the policy.
+func NewHTTPClient(p FetchPolicy) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if p.DenyPrivateNetworks {
+		transport.Proxy = nil
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   denyPrivateNetworks,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{
+		Timeout: p.Timeout,
+		Transport: &policyTransport{
+			next:    transport,
+			schemes: p.AllowedSchemes,
+			maxBody: p.MaxBodyBytes,
+		},
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) > p.MaxRedirects {
+				return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, p.MaxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// policyTransport checks the scheme of every request, redirects included,
+// and limits the size of response bodies.
+type policyTransport struct {
+	next    http.RoundTripper
+	schemes []string
+	maxBody int64
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.schemes) > 0 && !slices.Contains(t.schemes, strings.ToLower(req.URL.Scheme)) {
+		return nil, fmt.Errorf("%w: %s", ErrSchemeNotAllowed, req.URL.Scheme)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || t.maxBody <= 0 {
+		return resp, err
+	}
+	if resp.ContentLength > t.maxBody {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", ErrBodyTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxBody}
+	return resp, nil
+}
+
+// limitedBody fails with ErrBodyTooLarge once more than the remaining bytes
+// are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	// read one byte past the limit to tell a body of exactly the limit
+	// apart from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// deniedPrefixes are the networks that are not publicly routable, see
+// FetchPolicy.DenyPrivateNetworks. IPv4-mapped IPv6 addresses are checked
+// as IPv4 addresses.
+//
+//nolint:gochecknoglobals // static list of networks
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space, carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("::/128"),          // unspecified
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// denyPrivateNetworks is a dialer control function that refuses to connect
+// to addresses in deniedPrefixes.
+func denyPrivateNetworks(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, address)
+	}
+	// prefixes never contain addresses with a zone
+	addr := addrPort.Addr().Unmap().WithZone("")
+	for _, p := range deniedPrefixes {
+		if p.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrAddressNotAllowed, addr)
+		}
+	}
+	return nil
+}
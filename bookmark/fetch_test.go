@@ -0,0 +1,183 @@
+package bookmark_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	page := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(htmlContent))
+	}
+
+	t.Run("default policy should fetch pages", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(page))
+		defer server.Close()
+
+		client := bookmark.NewHTTPClient(bookmark.DefaultFetchPolicy())
+		title, err := bookmark.FetchTitle(t.Context(), client, server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if title != "Example Domain" {
+			t.Errorf("expected title %q, got %q", "Example Domain", title)
+		}
+	})
+
+	t.Run("timeout should stop slow pages", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(done)
+
+		policy := bookmark.DefaultFetchPolicy()
+		policy.Timeout = 50 * time.Millisecond
+		_, err := bookmark.FetchMetadata(t.Context(), bookmark.NewHTTPClient(policy), server.URL)
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("expected timeout error, got %v", err)
+		}
+	})
+
+	t.Run("max body bytes should stop large pages", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{
+				name: "content length",
+				handler: func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+				},
+			},
+			{
+				name: "streamed",
+				handler: func(w http.ResponseWriter, _ *http.Request) {
+					for range 4 {
+						_, _ = w.Write([]byte(strings.Repeat("a", 512)))
+						w.(http.Flusher).Flush()
+					}
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+
+				policy := bookmark.DefaultFetchPolicy()
+				policy.MaxBodyBytes = 1024
+				_, err := bookmark.FetchMetadata(t.Context(), bookmark.NewHTTPClient(policy), server.URL)
+				if !errors.Is(err, bookmark.ErrBodyTooLarge) {
+					t.Errorf("expected ErrBodyTooLarge, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("max body bytes should allow pages up to the limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(page))
+		defer server.Close()
+
+		policy := bookmark.DefaultFetchPolicy()
+		policy.MaxBodyBytes = int64(len(htmlContent))
+		if _, err := bookmark.FetchMetadata(t.Context(), bookmark.NewHTTPClient(policy), server.URL); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("max redirects should stop redirect loops", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}))
+		defer server.Close()
+
+		policy := bookmark.DefaultFetchPolicy()
+		policy.MaxRedirects = 2
+		_, err := bookmark.FetchMetadata(t.Context(), bookmark.NewHTTPClient(policy), server.URL)
+		if !errors.Is(err, bookmark.ErrTooManyRedirects) {
+			t.Errorf("expected ErrTooManyRedirects, got %v", err)
+		}
+		if n := requests.Load(); n != 3 {
+			t.Errorf("expected 3 requests, got %d", n)
+		}
+	})
+
+	t.Run("allowed schemes should be checked", func(t *testing.T) {
+		client := bookmark.NewHTTPClient(bookmark.DefaultFetchPolicy())
+		_, err := bookmark.FetchMetadata(t.Context(), client, "ftp://example.com/file")
+		if !errors.Is(err, bookmark.ErrSchemeNotAllowed) {
+			t.Errorf("expected ErrSchemeNotAllowed, got %v", err)
+		}
+	})
+
+	t.Run("allowed schemes should be checked on redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		}))
+		defer server.Close()
+
+		client := bookmark.NewHTTPClient(bookmark.DefaultFetchPolicy())
+		_, err := bookmark.FetchMetadata(t.Context(), client, server.URL)
+		if !errors.Is(err, bookmark.ErrSchemeNotAllowed) {
+			t.Errorf("expected ErrSchemeNotAllowed, got %v", err)
+		}
+	})
+
+	t.Run("deny private networks should refuse loopback addresses", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			page(w, r)
+		}))
+		defer server.Close()
+
+		policy := bookmark.DefaultFetchPolicy()
+		policy.DenyPrivateNetworks = true
+		client := bookmark.NewHTTPClient(policy)
+		for _, u := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+			_, err := bookmark.FetchMetadata(t.Context(), client, u)
+			if !errors.Is(err, bookmark.ErrAddressNotAllowed) {
+				t.Errorf("expected ErrAddressNotAllowed for %s, got %v", u, err)
+			}
+		}
+		if n := requests.Load(); n != 0 {
+			t.Errorf("expected no requests, got %d", n)
+		}
+	})
+
+	t.Run("deny private networks should refuse addresses that are not public", func(t *testing.T) {
+		policy := bookmark.DefaultFetchPolicy()
+		policy.DenyPrivateNetworks = true
+		client := bookmark.NewHTTPClient(policy)
+		addresses := []string{
+			"0.0.0.0", "0.1.2.3", "10.0.0.1", "100.64.0.1", "100.100.100.200", "127.0.0.2", "169.254.169.254",
+			"172.16.0.1", "192.0.0.8", "192.0.2.1", "192.168.1.1", "198.18.0.1", "198.51.100.1", "203.0.113.1",
+			"224.0.0.1", "239.255.255.250", "240.0.0.1", "255.255.255.255",
+			"[::]", "[::1]", "[::ffff:10.0.0.1]", "[64:ff9b::a9fe:a9fe]", "[64:ff9b:1::1]", "[100::1]",
+			"[2001::1]", "[2001:db8::1]", "[2002:a00:1::1]", "[fd00::1]", "[fe80::1]", "[ff02::1]",
+		}
+		for _, a := range addresses {
+			_, err := bookmark.FetchMetadata(t.Context(), client, "http://"+a+":1/")
+			if !errors.Is(err, bookmark.ErrAddressNotAllowed) {
+				t.Errorf("expected ErrAddressNotAllowed for %s, got %v", a, err)
+			}
+		}
+	})
+}
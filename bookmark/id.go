@@ -0,0 +1,11 @@
+package bookmark
+
+import "github.com/oklog/ulid/v2"
+
+// NewID generates a new unique bookmark ID.
+//
+// IDs are ULIDs, so they sort by creation time and are safe to use in URLs
+// and on the command line.
+func NewID() string {
+	return ulid.Make().String()
+}
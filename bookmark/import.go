@@ -0,0 +1,152 @@
+package bookmark
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark/netscape"
+)
+
+// BatchStore is implemented by stores that add many bookmarks at once
+// faster than one by one.
+type BatchStore interface {
+	// AddAll adds the bookmarks. Either all of them are added or none.
+	AddAll(bookmarks []*Bookmark) error
+}
+
+// ImportSummary summarizes the result of an import.
+type ImportSummary struct {
+	Imported int
+	// Skipped counts entries whose URL is already in the library.
+	Skipped int
+	Failed  int
+	// Errors holds the reason for every failed entry.
+	Errors []error
+}
+
+// ImportOptions configures an import.
+type ImportOptions struct {
+	// FolderTags also tags the bookmarks with the names of their folders.
+	FolderTags bool
+}
+
+// ImportNetscape imports the bookmarks of a Netscape bookmark file, the
+// bookmarks.html format that browsers export. Folders become collections,
+// a slash in a folder name is escaped so the folder stays one collection.
+// Entries with a URL that is already in the library are skipped, URLs are
+// compared in their canonical form.
+func (l *Library) ImportNetscape(r io.Reader, opts ImportOptions) (*ImportSummary, error) {
+	entries, err := netscape.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse bookmark file: %w", err)
+	}
+	existing, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, b := range existing {
+		seen[l.canonicalizer.Key(b.Content)] = true
+	}
+	summary := &ImportSummary{}
+	var bookmarks []*Bookmark
+	for _, e := range entries {
+		if !isURL(e.URL) {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Errorf("entry %q has no valid url: %q", e.Title, e.URL))
+			continue
+		}
+		key := l.canonicalizer.Key(e.URL)
+		if seen[key] {
+			summary.Skipped++
+			continue
+		}
+		seen[key] = true
+		bookmarks = append(bookmarks, entryBookmark(e, l.now(), opts))
+	}
+	l.addAll(bookmarks, summary)
+	return summary, nil
+}
+
+// addAll adds imported bookmarks and their collections and counts them in
+// the summary. Stores that implement BatchStore add the bookmarks at once.
+func (l *Library) addAll(bookmarks []*Bookmark, summary *ImportSummary) {
+	fail := func(b *Bookmark, err error) {
+		summary.Failed++
+		summary.Errors = append(summary.Errors, fmt.Errorf("could not add %s: %w", b.Content, err))
+	}
+	// keep every collection once, bookmarks in a collection that cannot be
+	// kept are not added
+	kept := map[string]error{}
+	var add []*Bookmark
+	for _, b := range bookmarks {
+		err, ok := kept[b.Collection]
+		if !ok {
+			err = l.keepCollection(b.Collection)
+			kept[b.Collection] = err
+		}
+		if err != nil {
+			fail(b, err)
+			continue
+		}
+		add = append(add, b)
+	}
+	var added []*Bookmark
+	if s, ok := l.store.(BatchStore); ok && len(add) > 0 {
+		if err := l.change(func() error { return s.AddAll(add) }); err != nil {
+			for _, b := range add {
+				fail(b, err)
+			}
+			return
+		}
+		added = add
+	} else {
+		for _, b := range add {
+			if err := l.change(func() error { return l.store.Add(b) }); err != nil {
+				fail(b, err)
+				continue
+			}
+			added = append(added, b)
+		}
+	}
+	for _, b := range added {
+		l.indexPut(b)
+		l.logger.Debug("imported bookmark", slog.String("id", b.ID), slog.String("url", b.Content))
+		summary.Imported++
+	}
+}
+
+// entryBookmark converts a Netscape entry to a bookmark. URLs are normalized
+// like Add does, and entries without an add date are created at now.
+func entryBookmark(e *netscape.Entry, now time.Time, opts ImportOptions) *Bookmark {
+	title := e.Title
+	if title == "" {
+		title = e.URL
+	}
+	createdAt := e.AddDate
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	content := e.URL
+	if u, err := NormalizeURL(content); err == nil {
+		content = u
+	}
+	tags := e.Tags
+	if opts.FolderTags {
+		tags = append(slices.Clone(tags), e.Folders...)
+	}
+	names := make([]string, 0, len(e.Folders))
+	for _, f := range e.Folders {
+		names = append(names, EscapeCollectionName(f))
+	}
+	return &Bookmark{
+		Title:      title,
+		Content:    content,
+		Tags:       NormalizeTags(tags),
+		Collection: JoinCollection(names...),
+		CreatedAt:  createdAt,
+	}
+}
@@ -0,0 +1,119 @@
+package bookmark_test
+
+import (
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLibrary_ImportNetscape(t *testing.T) {
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	if err := store.Add(&bookmark.Bookmark{Title: "Go", Content: "https://go.dev/"}); err != nil {
+		t.Fatalf("Store.Add() error = %v", err)
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+
+	file := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>Dev Tools</H3>
+    <DL><p>
+        <DT><H3>Go</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/" ADD_DATE="1700000000">The Go Programming Language</A>
+            <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1700000000" TAGS="Go">Go Packages</A>
+            <DT><A HREF="http://pkg.go.dev?utm_source=x" ADD_DATE="1700000000">Go Packages again</A>
+        </DL><p>
+        <DT><A HREF="javascript:void(0)">Bookmarklet</A>
+    </DL><p>
+</DL><p>
+`
+	summary, err := lib.ImportNetscape(strings.NewReader(file), bookmark.ImportOptions{})
+	if err != nil {
+		t.Fatalf("Library.ImportNetscape() error = %v", err)
+	}
+	if summary.Imported != 1 || summary.Skipped != 2 || summary.Failed != 1 {
+		t.Errorf("Library.ImportNetscape() = %+v, want 1 imported, 2 skipped and 1 failed", summary)
+	}
+
+	bookmarks, err := lib.List()
+	if err != nil {
+		t.Fatalf("Library.List() error = %v", err)
+	}
+	want := []*bookmark.Bookmark{
+		{Title: "Go", Content: "https://go.dev/"},
+		{
+			Title:      "Go Packages",
+			Content:    "https://pkg.go.dev/",
+			Tags:       []string{"go"},
+			Collection: "Dev Tools/Go",
+			CreatedAt:  time.Unix(1700000000, 0).UTC(),
+		},
+	}
+	if diff := cmp.Diff(want, bookmarks, cmpopts.IgnoreFields(bookmark.Bookmark{}, "ID")); diff != "" {
+		t.Errorf("Library.List() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLibrary_ImportNetscapeFolders(t *testing.T) {
+	file := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>Dev Tools</H3>
+    <DL><p>
+        <DT><H3>Go/Rust</H3>
+        <DL><p>
+            <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1700000000" TAGS="Go">Go Packages</A>
+        </DL><p>
+    </DL><p>
+</DL><p>
+`
+	tests := []struct {
+		name string
+		opts bookmark.ImportOptions
+		want *bookmark.Bookmark
+	}{
+		{
+			name: "folders should become collections and keep their slashes",
+			want: &bookmark.Bookmark{
+				Title:      "Go Packages",
+				Content:    "https://pkg.go.dev/",
+				Tags:       []string{"go"},
+				Collection: `Dev Tools/Go\/Rust`,
+				CreatedAt:  time.Unix(1700000000, 0).UTC(),
+			},
+		},
+		{
+			name: "folder tags should also tag bookmarks with their folders",
+			opts: bookmark.ImportOptions{FolderTags: true},
+			want: &bookmark.Bookmark{
+				Title:      "Go Packages",
+				Content:    "https://pkg.go.dev/",
+				Tags:       []string{"dev-tools", "go", "go/rust"},
+				Collection: `Dev Tools/Go\/Rust`,
+				CreatedAt:  time.Unix(1700000000, 0).UTC(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), json.NewStore(path.Join(t.TempDir(), "test.json")))
+			if _, err := lib.ImportNetscape(strings.NewReader(file), tt.opts); err != nil {
+				t.Fatalf("Library.ImportNetscape() error = %v", err)
+			}
+			bookmarks, err := lib.List()
+			if err != nil {
+				t.Fatalf("Library.List() error = %v", err)
+			}
+			want := []*bookmark.Bookmark{tt.want}
+			if diff := cmp.Diff(want, bookmarks, cmpopts.IgnoreFields(bookmark.Bookmark{}, "ID")); diff != "" {
+				t.Errorf("Library.List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package json
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+var _ bookmark.CollectionStore = &Store{}
+
+// collectionsSuffix is appended to the path of the JSON file to get the
+// path of the file with the collections.
+const collectionsSuffix = ".collections"
+
+// Collection is a struct that represents a collection.
+type Collection struct {
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// AddCollection implements bookmark.CollectionStore.
+func (s *Store) AddCollection(c *bookmark.Collection) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	collections, err := readList[*Collection](s.collectionsPath())
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(collections, func(e *Collection) bool { return e.Path == c.Path }) {
+		return nil
+	}
+	collections = append(collections, &Collection{Path: c.Path, CreatedAt: c.CreatedAt})
+	slices.SortFunc(collections, func(a, b *Collection) int {
+		return bookmark.CompareCollections(a.Path, b.Path)
+	})
+	return writeList(s.collectionsPath(), collections)
+}
+
+// Collections implements bookmark.CollectionStore.
+func (s *Store) Collections() ([]*bookmark.Collection, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	r, err := readList[*Collection](s.collectionsPath())
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]*bookmark.Collection, 0, len(r))
+	for _, e := range r {
+		collections = append(collections, &bookmark.Collection{Path: e.Path, CreatedAt: e.CreatedAt})
+	}
+	return collections, nil
+}
+
+// DeleteCollection implements bookmark.CollectionStore.
+func (s *Store) DeleteCollection(path string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	collections, err := readList[*Collection](s.collectionsPath())
+	if err != nil {
+		return err
+	}
+	n := len(collections)
+	collections = slices.DeleteFunc(collections, func(e *Collection) bool { return e.Path == path })
+	if len(collections) == n {
+		return fmt.Errorf("%w: %s", bookmark.ErrCollectionNotFound, path)
+	}
+	return writeList(s.collectionsPath(), collections)
+}
+
+// collectionsPath returns the path of the file with the collections.
+func (s *Store) collectionsPath() string {
+	return s.filePath + collectionsSuffix
+}
@@ -0,0 +1,131 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes a file by calling write with a temporary file in
+// the same directory. Once the temporary file is written and synced to
+// disk, the current file is kept at backupPath and the temporary file is
+// renamed over it. A crash therefore never leaves a partially written file
+// behind, and the file exists at all times.
+func writeFileAtomic(filePath, backupPath string, write func(w io.Writer) error) error {
+	dir, base := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// the temporary file is gone after a successful rename
+	defer os.Remove(tmp.Name())
+
+	if err = write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = backup(filePath, backupPath); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filePath); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// backup keeps the current version of a file at backupPath, as a hard link
+// or as a copy where hard links are not supported. The backup is replaced
+// by a rename, so there always is a complete one. A missing file keeps the
+// previous backup.
+func backup(filePath, backupPath string) error {
+	if _, err := os.Stat(filePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	tmpPath := backupPath + tmpSuffix
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Link(filePath, tmpPath); err != nil {
+		if err = copyFile(filePath, tmpPath); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	return os.Rename(tmpPath, backupPath)
+}
+
+// copyFile copies a file and syncs the copy to disk.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir syncs a directory so renames in it are durable. Not every
+// platform supports syncing directories, so this is best effort.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
+
+// readList reads a JSON array from a file next to the bookmarks file. A
+// missing file holds an empty list.
+func readList[T any](filePath string) ([]T, error) {
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var list []T
+	if err = json.NewDecoder(file).Decode(&list); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorrupt, filePath, err)
+	}
+	return list, nil
+}
+
+// writeList writes a JSON array to a file next to the bookmarks file, in
+// the same way as save writes the bookmarks.
+func writeList[T any](filePath string, list []T) error {
+	return writeFileAtomic(filePath, filePath+backupSuffix, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(list)
+	})
+}
@@ -3,6 +3,9 @@ package json
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"sync"
 	"time"
@@ -12,80 +15,275 @@ import (
 
 var _ bookmark.Store = &Store{}
 
+var _ bookmark.BatchStore = &Store{}
+
+var _ bookmark.VersionedStore = &Store{}
+
 var (
 	// ErrNotFound is returned when a bookmark is not found.
-	ErrNotFound = errors.New("bookmark not found")
+	ErrNotFound = bookmark.ErrNotFound
+	// ErrCorrupt is returned when a JSON file cannot be decoded.
+	ErrCorrupt = errors.New("corrupt bookmarks file")
+)
+
+const (
+	// backupSuffix is appended to the path of the JSON file to get the
+	// path of the backup of the previous version.
+	backupSuffix = ".bak"
+	// corruptSuffix is appended to the path of a corrupt JSON file when
+	// it is replaced by its backup.
+	corruptSuffix = ".corrupt"
+	// tmpSuffix is appended to the path of a backup while it is created.
+	tmpSuffix = ".tmp"
 )
 
 // Bookmark is a struct that represents a bookmark.
 type Bookmark struct {
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+	// Collection is only written for bookmarks in a collection.
+	Collection string    `json:"collection,omitempty"`
+	Metadata   *Metadata `json:"metadata,omitempty"`
+	// PendingMetadata is only written for bookmarks that are pending.
+	PendingMetadata bool      `json:"pending_metadata,omitempty"`
+	Health          *Health   `json:"health,omitempty"`
+	Snapshot        *Snapshot `json:"snapshot,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at,omitzero"`
+}
+
+// Metadata is a struct that represents the metadata of a page.
+type Metadata struct {
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	SiteName     string    `json:"site_name,omitempty"`
+	CanonicalURL string    `json:"canonical_url,omitempty"`
+	FaviconURL   string    `json:"favicon_url,omitempty"`
+	ImageURL     string    `json:"image_url,omitempty"`
+	Language     string    `json:"language,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at,omitzero"`
+}
+
+// Health is a struct that represents the result of a link check.
+type Health struct {
+	StatusCode        int       `json:"status_code,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	FinalURL          string    `json:"final_url,omitempty"`
+	PermanentRedirect bool      `json:"permanent_redirect,omitempty"`
+	Failures          int       `json:"failures,omitempty"`
+	CheckedAt         time.Time `json:"checked_at,omitzero"`
+}
+
+// Snapshot is a struct that represents an archived copy of a page.
+type Snapshot struct {
+	Hash       string    `json:"hash"`
+	Path       string    `json:"path"`
+	Format     string    `json:"format"`
+	ArchivedAt time.Time `json:"archived_at,omitzero"`
 }
 
 // Map maps a bookmark.Bookmark to a Bookmark.
 func (b *Bookmark) Map(i *bookmark.Bookmark) {
+	b.ID = i.ID
 	b.Title = i.Title
 	b.Content = i.Content
+	b.Tags = i.Tags
+	b.Collection = i.Collection
+	b.Metadata = nil
+	if i.Metadata != (bookmark.Metadata{}) {
+		m := Metadata(i.Metadata)
+		b.Metadata = &m
+	}
+	b.PendingMetadata = i.PendingMetadata
+	b.Health = nil
+	if i.Health != (bookmark.Health{}) {
+		h := Health(i.Health)
+		b.Health = &h
+	}
+	b.Snapshot = nil
+	if i.Snapshot != (bookmark.Snapshot{}) {
+		s := Snapshot(i.Snapshot)
+		b.Snapshot = &s
+	}
 	b.CreatedAt = i.CreatedAt
+	b.UpdatedAt = i.UpdatedAt
 }
 
 // Unmap maps a Bookmark to a bookmark.Bookmark.
 func (b *Bookmark) Unmap() *bookmark.Bookmark {
-	return &bookmark.Bookmark{
-		Title:     b.Title,
-		Content:   b.Content,
-		CreatedAt: b.CreatedAt,
+	u := &bookmark.Bookmark{
+		ID:              b.ID,
+		Title:           b.Title,
+		Content:         b.Content,
+		Tags:            b.Tags,
+		Collection:      b.Collection,
+		PendingMetadata: b.PendingMetadata,
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
 	}
+	if b.Metadata != nil {
+		u.Metadata = bookmark.Metadata(*b.Metadata)
+	}
+	if b.Health != nil {
+		u.Health = bookmark.Health(*b.Health)
+	}
+	if b.Snapshot != nil {
+		u.Snapshot = bookmark.Snapshot(*b.Snapshot)
+	}
+	return u
 }
 
 // Store is a struct that represents a json store.
+//
+// Every operation holds an advisory lock on a lock file next to the JSON
+// file, so multiple processes can safely use the same store.
 type Store struct {
-	filePath string
-	mutex    sync.Mutex
+	filePath    string
+	lockTimeout time.Duration
+	mutex       sync.Mutex
+}
+
+// DefaultLockTimeout is the default time to wait for the lock on the JSON
+// file.
+const DefaultLockTimeout = 5 * time.Second
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithLockTimeout sets how long to wait for another process to release the
+// lock on the JSON file before failing with ErrLockTimeout.
+func WithLockTimeout(d time.Duration) Option {
+	return func(s *Store) {
+		s.lockTimeout = d
+	}
 }
 
 // NewStore creates a new json store.
-func NewStore(filePath string) *Store {
-	return &Store{
-		filePath: filePath,
+func NewStore(filePath string, opts ...Option) *Store {
+	s := &Store{
+		filePath:    filePath,
+		lockTimeout: DefaultLockTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// lock locks the store for this process and for other processes. The
+// returned function releases the lock.
+func (s *Store) lock() (func(), error) {
+	s.mutex.Lock()
+	l, err := lockFile(s.filePath+lockSuffix, s.lockTimeout)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
 	}
+	return func() {
+		_ = l.unlock()
+		s.mutex.Unlock()
+	}, nil
 }
 
 // Add implements bookmark.Store.
 func (s *Store) Add(b *bookmark.Bookmark) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	e := &Bookmark{}
-	e.Map(b)
+	return s.AddAll([]*bookmark.Bookmark{b})
+}
+
+// AddAll implements bookmark.BatchStore. The file is written once for all
+// bookmarks.
+func (s *Store) AddAll(bookmarks []*bookmark.Bookmark) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Load existing bookmarks
-	bookmarks, err := s.load()
+	existing, err := s.load()
 	if err != nil {
 		return err
 	}
 
-	// Append the new bookmark
-	bookmarks = append(bookmarks, e)
+	// Append the new bookmarks
+	for _, b := range bookmarks {
+		if b.ID == "" {
+			b.ID = bookmark.NewID()
+		}
+		e := &Bookmark{}
+		e.Map(b)
+		existing = append(existing, e)
+	}
 
 	// Save back to file
-	return s.save(bookmarks)
+	return s.save(existing)
+}
+
+// Get implements bookmark.Store.
+func (s *Store) Get(id string) (*bookmark.Bookmark, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	bookmarks, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookmarks {
+		if b.ID == id {
+			return b.Unmap(), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Update implements bookmark.Store.
+func (s *Store) Update(id string, p *bookmark.Patch) (*bookmark.Bookmark, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	bookmarks, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookmarks {
+		if b.ID != id {
+			continue
+		}
+		// Apply the patch to the domain bookmark and map it back
+		u := b.Unmap()
+		p.Apply(u)
+		b.Map(u)
+		if err = s.save(bookmarks); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+	return nil, ErrNotFound
 }
 
 // Delete implements bookmark.Store.
-func (s *Store) Delete(title string) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+func (s *Store) Delete(id string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	// Load existing bookmarks
 	bookmarks, err := s.load()
 	if err != nil {
 		return err
 	}
-	// Filter out the bookmark with the given title
+	// Filter out the bookmark with the given id
 	var newBookmarks []*Bookmark
 	for _, b := range bookmarks {
-		if b.Title != title {
+		if b.ID != id {
 			newBookmarks = append(newBookmarks, b)
 		}
 	}
@@ -98,8 +296,11 @@ func (s *Store) Delete(title string) error {
 
 // List implements bookmark.Store.
 func (s *Store) List() ([]*bookmark.Bookmark, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	// Load existing bookmarks
 	r, err := s.load()
 	if err != nil {
@@ -113,12 +314,63 @@ func (s *Store) List() ([]*bookmark.Bookmark, error) {
 	return bookmarks, nil
 }
 
-// load reads the JSON file and returns the list of bookmarks.
+// Version implements bookmark.VersionedStore. It is the modification time
+// and size of the JSON file, which is replaced on every change.
+func (s *Store) Version() (string, error) {
+	info, err := os.Stat(s.filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "0-0", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// load reads the JSON file and returns the list of bookmarks. A missing
+// file holds no bookmarks. Files written before bookmarks had IDs are
+// upgraded in place. When the file cannot be decoded, the backup of the
+// previous version is restored.
 func (s *Store) load() ([]*Bookmark, error) {
-	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
+	bookmarks, err := s.decode(s.filePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
 		return []*Bookmark{}, nil
+	case errors.Is(err, ErrCorrupt):
+		if bookmarks, err = s.restore(err); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
 	}
-	file, err := os.Open(s.filePath)
+	if assignIDs(bookmarks) {
+		if err = s.save(bookmarks); err != nil {
+			return nil, err
+		}
+	}
+	return bookmarks, nil
+}
+
+// restore restores the bookmarks from the backup file. The corrupt file is
+// kept next to it for inspection, cause is the reason it is corrupt.
+func (s *Store) restore(cause error) ([]*Bookmark, error) {
+	bookmarks, err := s.decode(s.backupPath())
+	if err != nil {
+		return nil, fmt.Errorf("could not restore backup of corrupt file: %w", errors.Join(cause, err))
+	}
+	if err = os.Rename(s.filePath, s.filePath+corruptSuffix); err != nil {
+		return nil, err
+	}
+	if err = s.save(bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// decode decodes the bookmarks in the given JSON file. Errors caused by
+// invalid or truncated JSON wrap ErrCorrupt.
+func (s *Store) decode(filePath string) ([]*Bookmark, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -126,20 +378,36 @@ func (s *Store) load() ([]*Bookmark, error) {
 	var bookmarks []*Bookmark
 	decoder := json.NewDecoder(file)
 	if err = decoder.Decode(&bookmarks); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorrupt, filePath, err)
 	}
 	return bookmarks, nil
 }
 
-// save writes the list of bookmarks to the JSON file.
-func (s *Store) save(bookmarks []*Bookmark) error {
-	file, err := os.Create(s.filePath) // Overwrite the file
-	if err != nil {
-		return err
+// assignIDs gives every bookmark without an ID a new one. It reports
+// whether any bookmark was changed.
+func assignIDs(bookmarks []*Bookmark) bool {
+	changed := false
+	for _, b := range bookmarks {
+		if b.ID == "" {
+			b.ID = bookmark.NewID()
+			changed = true
+		}
 	}
-	defer file.Close()
+	return changed
+}
+
+// save writes the list of bookmarks to the JSON file. The bookmarks are
+// written to a temporary file first, which replaces the file once it is
+// completely written, and the previous version is kept as a backup.
+func (s *Store) save(bookmarks []*Bookmark) error {
+	return writeFileAtomic(s.filePath, s.backupPath(), func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ") // Pretty print JSON
+		return encoder.Encode(bookmarks)
+	})
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ") // Pretty print JSON
-	return encoder.Encode(bookmarks)
+// backupPath returns the path of the backup of the JSON file.
+func (s *Store) backupPath() string {
+	return s.filePath + backupSuffix
 }
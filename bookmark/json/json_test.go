@@ -1,6 +1,7 @@
 package json_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -14,6 +15,7 @@ import (
 
 func TestBookmark_Map(t *testing.T) {
 	type fields struct {
+		ID        string
 		Title     string
 		Content   string
 		CreatedAt time.Time
@@ -30,27 +32,50 @@ func TestBookmark_Map(t *testing.T) {
 		{
 			name: "Test 1",
 			fields: fields{
+				ID:        "01JQ0000000000000000000001",
 				Title:     "Test 1",
 				Content:   "Test 1",
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 			args: args{
 				i: &bookmark.Bookmark{
+					ID:        "01JQ0000000000000000000001",
 					Title:     "Test 1",
 					Content:   "Test 1",
 					CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 				},
 			},
 			want: &json.Bookmark{
+				ID:        "01JQ0000000000000000000001",
 				Title:     "Test 1",
 				Content:   "Test 1",
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 		},
+		{
+			name: "Pending metadata",
+			args: args{
+				i: &bookmark.Bookmark{
+					ID:              "01JQ0000000000000000000002",
+					Title:           "https://example.com",
+					Content:         "https://example.com",
+					PendingMetadata: true,
+					CreatedAt:       time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			want: &json.Bookmark{
+				ID:              "01JQ0000000000000000000002",
+				Title:           "https://example.com",
+				Content:         "https://example.com",
+				PendingMetadata: true,
+				CreatedAt:       time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := &json.Bookmark{
+				ID:        tt.fields.ID,
 				Title:     tt.fields.Title,
 				Content:   tt.fields.Content,
 				CreatedAt: tt.fields.CreatedAt,
@@ -65,6 +90,7 @@ func TestBookmark_Map(t *testing.T) {
 
 func TestBookmark_Unmap(t *testing.T) {
 	type fields struct {
+		ID        string
 		Title     string
 		Content   string
 		CreatedAt time.Time
@@ -77,11 +103,13 @@ func TestBookmark_Unmap(t *testing.T) {
 		{
 			name: "Test 1",
 			fields: fields{
+				ID:        "01JQ0000000000000000000001",
 				Title:     "Test 1",
 				Content:   "Test 1",
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 			want: &bookmark.Bookmark{
+				ID:        "01JQ0000000000000000000001",
 				Title:     "Test 1",
 				Content:   "Test 1",
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -91,6 +119,7 @@ func TestBookmark_Unmap(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			b := &json.Bookmark{
+				ID:        tt.fields.ID,
 				Title:     tt.fields.Title,
 				Content:   tt.fields.Content,
 				CreatedAt: tt.fields.CreatedAt,
@@ -108,6 +137,7 @@ func TestStore_Add(t *testing.T) {
 		filePath := path.Join(t.TempDir(), "test.json")
 		store := json.NewStore(filePath)
 		b := &bookmark.Bookmark{
+			ID:        "01JQ0000000000000000000001",
 			Title:     "Test 1",
 			Content:   "Test 1",
 			CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
@@ -117,6 +147,7 @@ func TestStore_Add(t *testing.T) {
 		}
 		expect := `[
   {
+    "id": "01JQ0000000000000000000001",
     "title": "Test 1",
     "content": "Test 1",
     "created_at": "2021-01-01T00:00:00Z"
@@ -132,6 +163,56 @@ func TestStore_Add(t *testing.T) {
 			t.Errorf("Store.Add() mismatch (-want +got):\n%s", diff)
 		}
 	})
+
+	t.Run("add should assign an id to a bookmark without one", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		b := &bookmark.Bookmark{
+			Title:   "Test 1",
+			Content: "Test 1",
+		}
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		if b.ID == "" {
+			t.Fatal("Store.Add() did not assign an id")
+		}
+		bookmarks, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 1 || bookmarks[0].ID != b.ID {
+			t.Errorf("Store.List() = %v, want bookmark with id %q", bookmarks, b.ID)
+		}
+	})
+}
+
+func TestStore_AddAll(t *testing.T) {
+	t.Run("add all should append every bookmark", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		if err := store.Add(&bookmark.Bookmark{Title: "Test 1", Content: "Test 1"}); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		if err := store.AddAll([]*bookmark.Bookmark{
+			{Title: "Test 2", Content: "Test 2"},
+			{Title: "Test 3", Content: "Test 3"},
+		}); err != nil {
+			t.Fatalf("Store.AddAll() error = %v", err)
+		}
+		bookmarks, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		var titles []string
+		for _, b := range bookmarks {
+			if b.ID == "" {
+				t.Errorf("Store.AddAll() did not assign an id to %q", b.Title)
+			}
+			titles = append(titles, b.Title)
+		}
+		if diff := cmp.Diff([]string{"Test 1", "Test 2", "Test 3"}, titles); diff != "" {
+			t.Errorf("Store.List() mismatch (-want +got):\n%s", diff)
+		}
+	})
 }
 
 func TestStore_Delete(t *testing.T) {
@@ -140,6 +221,7 @@ func TestStore_Delete(t *testing.T) {
 		store := json.NewStore(filePath)
 		for i := range 3 {
 			b := &bookmark.Bookmark{
+				ID:        fmt.Sprintf("01JQ000000000000000000000%d", i),
 				Title:     fmt.Sprintf("Test %d", i),
 				Content:   fmt.Sprintf("Test %d", i),
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, i, time.UTC),
@@ -148,16 +230,18 @@ func TestStore_Delete(t *testing.T) {
 				t.Errorf("Store.Add() error = %v", err)
 			}
 		}
-		if err := store.Delete("Test 1"); err != nil {
+		if err := store.Delete("01JQ0000000000000000000001"); err != nil {
 			t.Errorf("Store.Delete() error = %v", err)
 		}
 		expect := `[
   {
+    "id": "01JQ0000000000000000000000",
     "title": "Test 0",
     "content": "Test 0",
     "created_at": "2021-01-01T00:00:00Z"
   },
   {
+    "id": "01JQ0000000000000000000002",
     "title": "Test 2",
     "content": "Test 2",
     "created_at": "2021-01-01T00:00:00.000000002Z"
@@ -172,6 +256,37 @@ func TestStore_Delete(t *testing.T) {
 			t.Errorf("Store.Delete() mismatch (-want +got):\n%s", diff)
 		}
 	})
+
+	t.Run("delete should keep bookmarks with the same title", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		for i := range 2 {
+			b := &bookmark.Bookmark{
+				ID:      fmt.Sprintf("01JQ000000000000000000000%d", i),
+				Title:   "Same title",
+				Content: fmt.Sprintf("Test %d", i),
+			}
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		if err := store.Delete("01JQ0000000000000000000000"); err != nil {
+			t.Fatalf("Store.Delete() error = %v", err)
+		}
+		bookmarks, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 1 || bookmarks[0].ID != "01JQ0000000000000000000001" {
+			t.Errorf("Store.List() = %v, want only bookmark 01JQ0000000000000000000001", bookmarks)
+		}
+	})
+
+	t.Run("delete should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		if err := store.Delete("unknown"); !errors.Is(err, json.ErrNotFound) {
+			t.Errorf("Store.Delete() error = %v, want %v", err, json.ErrNotFound)
+		}
+	})
 }
 
 func TestStore_List(t *testing.T) {
@@ -180,6 +295,7 @@ func TestStore_List(t *testing.T) {
 		store := json.NewStore(filePath)
 		for i := range 2 {
 			b := &bookmark.Bookmark{
+				ID:        fmt.Sprintf("01JQ000000000000000000000%d", i),
 				Title:     fmt.Sprintf("Test %d", i),
 				Content:   fmt.Sprintf("Test %d", i),
 				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, i, time.UTC),
@@ -190,11 +306,13 @@ func TestStore_List(t *testing.T) {
 		}
 		expect := `[
   {
+    "id": "01JQ0000000000000000000000",
     "title": "Test 0",
     "content": "Test 0",
     "created_at": "2021-01-01T00:00:00Z"
   },
   {
+    "id": "01JQ0000000000000000000001",
     "title": "Test 1",
     "content": "Test 1",
     "created_at": "2021-01-01T00:00:00.000000001Z"
@@ -209,4 +327,231 @@ func TestStore_List(t *testing.T) {
 			t.Errorf("Store.Delete() mismatch (-want +got):\n%s", diff)
 		}
 	})
+
+	t.Run("list should upgrade bookmarks without an id", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "test.json")
+		legacy := `[{"title": "Test 0", "content": "Test 0", "created_at": "2021-01-01T00:00:00Z"}]`
+		if err := os.WriteFile(filePath, []byte(legacy), 0600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		store := json.NewStore(filePath)
+		bookmarks, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 1 || bookmarks[0].ID == "" {
+			t.Fatalf("Store.List() = %v, want one bookmark with an id", bookmarks)
+		}
+		// the assigned id should be stable across loads
+		again, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if again[0].ID != bookmarks[0].ID {
+			t.Errorf("id changed between loads: %q != %q", bookmarks[0].ID, again[0].ID)
+		}
+	})
+}
+
+func TestStore_Get(t *testing.T) {
+	t.Run("get should return the bookmark with the given id", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		for i := range 2 {
+			b := &bookmark.Bookmark{
+				ID:        fmt.Sprintf("01JQ000000000000000000000%d", i),
+				Title:     fmt.Sprintf("Test %d", i),
+				Content:   fmt.Sprintf("Test %d", i),
+				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		got, err := store.Get("01JQ0000000000000000000001")
+		if err != nil {
+			t.Fatalf("Store.Get() error = %v", err)
+		}
+		want := &bookmark.Bookmark{
+			ID:        "01JQ0000000000000000000001",
+			Title:     "Test 1",
+			Content:   "Test 1",
+			CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Get() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		if _, err := store.Get("unknown"); !errors.Is(err, json.ErrNotFound) {
+			t.Errorf("Store.Get() error = %v, want %v", err, json.ErrNotFound)
+		}
+	})
+}
+
+func TestStore_Update(t *testing.T) {
+	t.Run("update should patch the bookmark and keep created at", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "test.json")
+		store := json.NewStore(filePath)
+		b := &bookmark.Bookmark{
+			ID:        "01JQ0000000000000000000001",
+			Title:     "Tset 1",
+			Content:   "Test 1",
+			CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		title := "Test 1"
+		got, err := store.Update(b.ID, &bookmark.Patch{
+			Title:     &title,
+			UpdatedAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("Store.Update() error = %v", err)
+		}
+		want := &bookmark.Bookmark{
+			ID:        "01JQ0000000000000000000001",
+			Title:     "Test 1",
+			Content:   "Test 1",
+			CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Update() mismatch (-want +got):\n%s", diff)
+		}
+		expect := `[
+  {
+    "id": "01JQ0000000000000000000001",
+    "title": "Test 1",
+    "content": "Test 1",
+    "created_at": "2021-01-01T00:00:00Z",
+    "updated_at": "2021-01-02T00:00:00Z"
+  }
+]
+` // trailing newline
+		file, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Errorf("failed to read file: %v", err)
+		}
+		if diff := cmp.Diff(expect, string(file)); diff != "" {
+			t.Errorf("Store.Update() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("update should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		if _, err := store.Update("unknown", &bookmark.Patch{}); !errors.Is(err, json.ErrNotFound) {
+			t.Errorf("Store.Update() error = %v, want %v", err, json.ErrNotFound)
+		}
+	})
+}
+
+func TestStore_save(t *testing.T) {
+	t.Run("save should keep the previous version as backup", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := path.Join(dir, "test.json")
+		store := json.NewStore(filePath)
+		for i := range 2 {
+			b := &bookmark.Bookmark{
+				ID:        fmt.Sprintf("01JQ000000000000000000000%d", i),
+				Title:     fmt.Sprintf("Test %d", i),
+				Content:   fmt.Sprintf("Test %d", i),
+				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		expect := `[
+  {
+    "id": "01JQ0000000000000000000000",
+    "title": "Test 0",
+    "content": "Test 0",
+    "created_at": "2021-01-01T00:00:00Z"
+  }
+]
+` // trailing newline
+		file, err := os.ReadFile(filePath + ".bak")
+		if err != nil {
+			t.Fatalf("failed to read backup: %v", err)
+		}
+		if diff := cmp.Diff(expect, string(file)); diff != "" {
+			t.Errorf("backup mismatch (-want +got):\n%s", diff)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read dir: %v", err)
+		}
+		for _, e := range entries {
+			switch e.Name() {
+			case "test.json", "test.json.bak", "test.json.lock":
+			default:
+				t.Errorf("unexpected file %q", e.Name())
+			}
+		}
+	})
+}
+
+func TestStore_load(t *testing.T) {
+	setup := func(t *testing.T) string {
+		t.Helper()
+		filePath := path.Join(t.TempDir(), "test.json")
+		store := json.NewStore(filePath)
+		for i := range 2 {
+			b := &bookmark.Bookmark{
+				ID:      fmt.Sprintf("01JQ000000000000000000000%d", i),
+				Title:   fmt.Sprintf("Test %d", i),
+				Content: fmt.Sprintf("Test %d", i),
+			}
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		return filePath
+	}
+
+	t.Run("load should restore the backup of a corrupt file", func(t *testing.T) {
+		filePath := setup(t)
+		if err := os.WriteFile(filePath, []byte(`[{"id": "01JQ00`), 0600); err != nil {
+			t.Fatalf("failed to corrupt file: %v", err)
+		}
+		bookmarks, err := json.NewStore(filePath).List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 1 || bookmarks[0].ID != "01JQ0000000000000000000000" {
+			t.Errorf("Store.List() = %v, want the bookmark from the backup", bookmarks)
+		}
+		if _, err = os.Stat(filePath + ".corrupt"); err != nil {
+			t.Errorf("expected the corrupt file to be kept: %v", err)
+		}
+	})
+
+	t.Run("load should not restore the backup of a removed file", func(t *testing.T) {
+		filePath := setup(t)
+		if err := os.Remove(filePath); err != nil {
+			t.Fatalf("failed to remove file: %v", err)
+		}
+		bookmarks, err := json.NewStore(filePath).List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 0 {
+			t.Errorf("Store.List() = %v, want no bookmarks", bookmarks)
+		}
+	})
+
+	t.Run("load should fail when the file and backup are corrupt", func(t *testing.T) {
+		filePath := setup(t)
+		for _, p := range []string{filePath, filePath + ".bak"} {
+			if err := os.WriteFile(p, []byte(`{`), 0600); err != nil {
+				t.Fatalf("failed to corrupt file: %v", err)
+			}
+		}
+		if _, err := json.NewStore(filePath).List(); !errors.Is(err, json.ErrCorrupt) {
+			t.Errorf("Store.List() error = %v, want %v", err, json.ErrCorrupt)
+		}
+	})
 }
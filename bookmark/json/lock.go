@@ -0,0 +1,58 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockSuffix is appended to the path of the JSON file to get the path
+	// of the lock file. The JSON file itself cannot be locked because it is
+	// replaced on every save.
+	lockSuffix = ".lock"
+	// lockRetryInterval is the time between attempts to acquire a lock
+	// that is held by another process.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when the lock on the JSON file could not be
+// acquired in time, usually because another process is using it.
+var ErrLockTimeout = errors.New("timeout acquiring lock")
+
+// fileLock is an advisory lock on a file shared between processes.
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile acquires an exclusive lock on the file at the given path,
+// creating it if needed. It retries until the timeout expires; a timeout of
+// zero tries only once.
+func lockFile(filePath string, timeout time.Duration) (*fileLock, error) {
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, lErr := tryLock(f)
+		if lErr != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock %s: %w", filePath, lErr)
+		}
+		if ok {
+			return &fileLock{file: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w on %s after %s, is another bookmarks process running?", ErrLockTimeout, filePath, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	return errors.Join(unlock(l.file), l.file.Close())
+}
@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package json
+
+import "os"
+
+// tryLock always succeeds on platforms without file locking, only the
+// in-process mutex protects the file there.
+func tryLock(_ *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlock is a no-op on platforms without file locking.
+func unlock(_ *os.File) error {
+	return nil
+}
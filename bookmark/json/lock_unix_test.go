@@ -0,0 +1,73 @@
+//go:build unix
+
+package json_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"golang.org/x/sys/unix"
+)
+
+func TestStore_lock(t *testing.T) {
+	t.Run("operations should time out while another process holds the lock", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "test.json")
+		f, err := os.OpenFile(filePath+".lock", os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			t.Fatalf("failed to open lock file: %v", err)
+		}
+		defer f.Close()
+		if err = unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+			t.Fatalf("failed to lock: %v", err)
+		}
+		store := json.NewStore(filePath, json.WithLockTimeout(50*time.Millisecond))
+		if err = store.Add(&bookmark.Bookmark{Title: "Test"}); !errors.Is(err, json.ErrLockTimeout) {
+			t.Errorf("Store.Add() error = %v, want %v", err, json.ErrLockTimeout)
+		}
+		if err = unix.Flock(int(f.Fd()), unix.LOCK_UN); err != nil {
+			t.Fatalf("failed to unlock: %v", err)
+		}
+		if err = store.Add(&bookmark.Bookmark{Title: "Test"}); err != nil {
+			t.Errorf("Store.Add() error = %v", err)
+		}
+	})
+
+	t.Run("concurrent stores should not lose writes", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "test.json")
+		const stores, adds = 4, 10
+		var wg sync.WaitGroup
+		errs := make(chan error, stores*adds)
+		for i := range stores {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// every store has its own lock file handle, like separate processes
+				store := json.NewStore(filePath)
+				for j := range adds {
+					errs <- store.Add(&bookmark.Bookmark{Title: fmt.Sprintf("Test %d-%d", i, j)})
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		bookmarks, err := json.NewStore(filePath).List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != stores*adds {
+			t.Errorf("Store.List() returned %d bookmarks, want %d", len(bookmarks), stores*adds)
+		}
+	})
+}
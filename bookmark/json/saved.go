@@ -0,0 +1,107 @@
+package json
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+)
+
+var _ bookmark.SearchStore = &Store{}
+
+// searchesSuffix is appended to the path of the JSON file to get the path of
+// the file with the saved searches. Keeping them apart leaves the format of
+// the bookmarks file unchanged.
+const searchesSuffix = ".searches"
+
+// SavedSearch is a struct that represents a saved search.
+type SavedSearch struct {
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Mode      string    `json:"mode,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// SaveSearch implements bookmark.SearchStore.
+func (s *Store) SaveSearch(search *bookmark.SavedSearch) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	searches, err := s.loadSearches()
+	if err != nil {
+		return err
+	}
+	searches = slices.DeleteFunc(searches, func(e *SavedSearch) bool { return e.Name == search.Name })
+	searches = append(searches, &SavedSearch{
+		Name:      search.Name,
+		Query:     search.Query,
+		Mode:      string(search.Mode),
+		CreatedAt: search.CreatedAt,
+	})
+	return s.saveSearches(searches)
+}
+
+// Searches implements bookmark.SearchStore.
+func (s *Store) Searches() ([]*bookmark.SavedSearch, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	r, err := s.loadSearches()
+	if err != nil {
+		return nil, err
+	}
+	searches := make([]*bookmark.SavedSearch, 0, len(r))
+	for _, e := range r {
+		searches = append(searches, &bookmark.SavedSearch{
+			Name:      e.Name,
+			Query:     e.Query,
+			Mode:      query.Mode(e.Mode),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return searches, nil
+}
+
+// DeleteSearch implements bookmark.SearchStore.
+func (s *Store) DeleteSearch(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	searches, err := s.loadSearches()
+	if err != nil {
+		return err
+	}
+	n := len(searches)
+	searches = slices.DeleteFunc(searches, func(e *SavedSearch) bool { return e.Name == name })
+	if len(searches) == n {
+		return fmt.Errorf("%w: %s", bookmark.ErrSearchNotFound, name)
+	}
+	return s.saveSearches(searches)
+}
+
+// loadSearches reads the saved searches. A missing file has none.
+func (s *Store) loadSearches() ([]*SavedSearch, error) {
+	return readList[*SavedSearch](s.searchesPath())
+}
+
+// saveSearches writes the saved searches sorted by name.
+func (s *Store) saveSearches(searches []*SavedSearch) error {
+	slices.SortFunc(searches, func(a, b *SavedSearch) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return writeList(s.searchesPath(), searches)
+}
+
+// searchesPath returns the path of the file with the saved searches.
+func (s *Store) searchesPath() string {
+	return s.filePath + searchesSuffix
+}
@@ -0,0 +1,144 @@
+package bookmark
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+)
+
+// Match is where the words of a search query match a field of a bookmark.
+type Match struct {
+	// Field is the name of the field: title, content, description, site or
+	// text.
+	Field  string
+	Ranges []Range
+}
+
+// textField is a field of a bookmark that words and phrases match.
+type textField struct {
+	name string
+	// weight is how much a match in the field counts when ranking.
+	weight float64
+	value  func(b *Bookmark) string
+}
+
+// textFields are the fields that words and phrases match, the page text
+// last.
+//
+//nolint:gochecknoglobals // static list of fields
+var textFields = []textField{
+	{name: "title", weight: 2, value: func(b *Bookmark) string { return b.Title }},
+	{name: "content", weight: 1, value: func(b *Bookmark) string { return b.Content }},
+	{name: "description", weight: 1, value: func(b *Bookmark) string { return b.Metadata.Description }},
+	{name: "site", weight: 1, value: func(b *Bookmark) string { return b.Metadata.SiteName }},
+	{name: "text", weight: 0.5, value: func(b *Bookmark) string { return b.Metadata.Text }},
+}
+
+// termFields returns the fields that a term matches, none for terms that
+// do not match text.
+func termFields(t *query.Term) []textField {
+	switch t.Field {
+	case query.FieldText:
+		if t.Mode == query.ModeFuzzy {
+			// the characters of a word are in almost any page
+			return textFields[:len(textFields)-1]
+		}
+		return textFields
+	case query.FieldTitle:
+		return textFields[:1]
+	case query.FieldURL:
+		return textFields[1:2]
+	default:
+		return nil
+	}
+}
+
+// Match reports whether the bookmark matches a query expression. A nil
+// expression matches every bookmark.
+func (b *Bookmark) Match(e query.Expr) bool {
+	switch e := e.(type) {
+	case nil:
+		return true
+	case *query.And:
+		return b.Match(e.Left) && b.Match(e.Right)
+	case *query.Or:
+		return b.Match(e.Left) || b.Match(e.Right)
+	case *query.Not:
+		return !b.Match(e.Expr)
+	case *query.Term:
+		return b.matchTerm(e)
+	case *query.DateRange:
+		if e.Field == query.FieldUpdated {
+			return e.Contains(b.UpdatedAt)
+		}
+		return e.Contains(b.CreatedAt)
+	case *query.IDs:
+		return slices.Contains(e.IDs, b.ID)
+	default:
+		return false
+	}
+}
+
+// matchTerm reports whether a field of the bookmark matches the term.
+func (b *Bookmark) matchTerm(t *query.Term) bool {
+	switch t.Field {
+	case query.FieldSite:
+		return query.MatchSite(b.Content, t.Value)
+	case query.FieldTag:
+		if t.Value == query.AnyTag {
+			return len(b.Tags) > 0
+		}
+		return b.HasTags(t.Value)
+	default:
+		return slices.ContainsFunc(termFields(t), func(f textField) bool {
+			_, ok := t.Match(f.value(b))
+			return ok
+		})
+	}
+}
+
+// rank returns how well the terms match the bookmark and where. The score
+// is the sum of the best weighted match of every term.
+func (b *Bookmark) rank(terms []*query.Term) (float64, []Match) {
+	var score float64
+	ranges := map[string][]Range{}
+	for _, t := range terms {
+		var best float64
+		for _, f := range termFields(t) {
+			m, ok := t.Match(f.value(b))
+			if !ok {
+				continue
+			}
+			best = max(best, m.Score*f.weight)
+			for _, r := range m.Ranges {
+				ranges[f.name] = append(ranges[f.name], Range(r))
+			}
+		}
+		score += best
+	}
+	var matches []Match
+	for _, f := range textFields {
+		if rs, ok := ranges[f.name]; ok {
+			matches = append(matches, Match{Field: f.name, Ranges: mergeRanges(rs)})
+		}
+	}
+	return score, matches
+}
+
+// mergeRanges sorts the ranges and merges the ones that overlap.
+func mergeRanges(ranges []Range) []Range {
+	slices.SortFunc(ranges, func(a, b Range) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
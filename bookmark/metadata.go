@@ -0,0 +1,381 @@
+package bookmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark/readability"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrTitleNotFound is returned when a page has no title.
+var ErrTitleNotFound = errors.New("title not found")
+
+// Metadata is the metadata of a web page. Bookmarks of URLs keep the
+// metadata of their page.
+type Metadata struct {
+	Title        string
+	Description  string
+	SiteName     string
+	CanonicalURL string
+	FaviconURL   string
+	ImageURL     string
+	Language     string
+	// Text is the readable text of the main content of the page.
+	Text string
+	// FetchedAt is when the page was fetched. It is zero for metadata that
+	// was never fetched.
+	FetchedAt time.Time
+}
+
+// FetchTitle retrieves the title of the given URL using an HTTP client.
+func FetchTitle(ctx context.Context, client *http.Client, url string) (string, error) {
+	md, err := FetchMetadata(ctx, client, url)
+	if err != nil {
+		return "", err
+	}
+	if md.Title == "" {
+		return "", ErrTitleNotFound
+	}
+	return md.Title, nil
+}
+
+// FetchMetadata retrieves the metadata of the given URL using an HTTP client.
+func FetchMetadata(ctx context.Context, client *http.Client, url string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch page: %s", resp.Status)
+	}
+
+	// The encoding is detected from a byte order mark, the Content-Type
+	// header or a <meta charset> in the first 1024 bytes, in that order.
+	r, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported page encoding: %w", err)
+	}
+	// the page is parsed twice, the size of the body is limited by the client
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// relative URLs are resolved against the URL after redirects
+	md, err := ExtractMetadata(bytes.NewReader(body), resp.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	md.Text = readability.Extract(doc)
+	return md, nil
+}
+
+// ExtractMetadata extracts the metadata of an UTF-8 encoded HTML page. Text
+// values have their whitespace collapsed and entities decoded. Every field is
+// taken from the best source available, in order of preference:
+//
+//   - Title: og:title, twitter:title, <title>, JSON-LD headline or name
+//   - Description: og:description, twitter:description, <meta name="description">, JSON-LD description
+//   - SiteName: og:site_name, application-name, JSON-LD publisher, host of the page
+//   - CanonicalURL: <link rel="canonical">, og:url, page URL
+//   - FaviconURL: <link rel="icon">, apple-touch-icon, /favicon.ico
+//   - ImageURL: og:image, twitter:image, JSON-LD image
+//   - Language: <html lang>, content-language, og:locale
+//
+// URLs are resolved against pageURL, which may be nil.
+func ExtractMetadata(r io.Reader, pageURL *url.URL) (*Metadata, error) {
+	s := &sources{meta: map[string]string{}, link: map[string]string{}}
+	if err := s.parse(r); err != nil {
+		return nil, err
+	}
+	base := pageURL
+	if s.base != "" {
+		base = resolve(pageURL, s.base)
+	}
+	md := &Metadata{
+		Title: cleanText(first(s.meta["og:title"], s.meta["twitter:title"], s.title, s.ld.title())),
+		Description: cleanText(first(
+			s.meta["og:description"], s.meta["twitter:description"], s.meta["description"], s.ld.Description,
+		)),
+		SiteName: cleanText(first(s.meta["og:site_name"], s.meta["application-name"], s.ld.publisher())),
+		Language: first(s.lang, s.meta["content-language"], strings.ReplaceAll(s.meta["og:locale"], "_", "-")),
+	}
+	if md.SiteName == "" && pageURL != nil {
+		md.SiteName = pageURL.Hostname()
+	}
+	if u := first(s.link["canonical"], s.meta["og:url"]); u != "" {
+		md.CanonicalURL = urlString(resolve(base, u))
+	} else if pageURL != nil {
+		md.CanonicalURL = pageURL.String()
+	}
+	if u := first(s.link["icon"], s.link["shortcut icon"], s.link["apple-touch-icon"]); u != "" {
+		md.FaviconURL = urlString(resolve(base, u))
+	} else if pageURL != nil {
+		md.FaviconURL = urlString(resolve(pageURL, "/favicon.ico"))
+	}
+	if u := first(s.meta["og:image"], s.meta["og:image:url"], s.meta["twitter:image"], s.ld.image()); u != "" {
+		md.ImageURL = urlString(resolve(base, u))
+	}
+	return md, nil
+}
+
+// sources are the raw values found in a page, before fallbacks are applied.
+type sources struct {
+	title string
+	lang  string
+	base  string
+	// meta holds the content of meta tags by lowercased name or property.
+	// Only the first occurrence is kept.
+	meta map[string]string
+	// link holds the href of link tags by lowercased rel.
+	link map[string]string
+	ld   jsonLD
+}
+
+// parse tokenizes the page and collects the sources.
+func (s *sources) parse(r io.Reader) error {
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return nil
+			}
+			return z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			s.tag(z, tok)
+		case html.TextToken, html.EndTagToken, html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
+
+// tag collects the sources of a start tag.
+func (s *sources) tag(z *html.Tokenizer, tok html.Token) {
+	switch tok.Data {
+	case "html":
+		s.lang = strings.TrimSpace(attr(tok, "lang"))
+	case "base":
+		if s.base == "" {
+			s.base = attr(tok, "href")
+		}
+	case "title":
+		if s.title == "" {
+			s.title = text(z, "title")
+		}
+	case "meta":
+		key := first(attr(tok, "property"), attr(tok, "name"), attr(tok, "http-equiv"))
+		key = strings.ToLower(strings.TrimSpace(key))
+		content := strings.TrimSpace(attr(tok, "content"))
+		if _, ok := s.meta[key]; key != "" && content != "" && !ok {
+			s.meta[key] = content
+		}
+	case "link":
+		rel := strings.ToLower(strings.TrimSpace(attr(tok, "rel")))
+		href := strings.TrimSpace(attr(tok, "href"))
+		if _, ok := s.link[rel]; rel != "" && href != "" && !ok {
+			s.link[rel] = href
+		}
+	case "script":
+		if attr(tok, "type") == "application/ld+json" && z.Next() == html.TextToken {
+			s.ld.merge(z.Token().Data)
+		}
+	}
+}
+
+// jsonLD holds the JSON-LD properties used for metadata.
+type jsonLD struct {
+	Name        string          `json:"name"`
+	Headline    string          `json:"headline"`
+	Description string          `json:"description"`
+	Image       json.RawMessage `json:"image"`
+	Publisher   json.RawMessage `json:"publisher"`
+	Graph       []jsonLD        `json:"@graph"`
+}
+
+// merge decodes a JSON-LD script and fills in the properties that are still
+// empty. Scripts can hold a single object, an array or an @graph of objects.
+func (ld *jsonLD) merge(data string) {
+	var objects []jsonLD
+	var single jsonLD
+	if err := json.Unmarshal([]byte(data), &single); err == nil {
+		objects = append(append(objects, single), single.Graph...)
+	} else if err = json.Unmarshal([]byte(data), &objects); err != nil {
+		return // invalid JSON-LD is ignored
+	}
+	for _, o := range objects {
+		ld.Name = first(ld.Name, o.Name)
+		ld.Headline = first(ld.Headline, o.Headline)
+		ld.Description = first(ld.Description, o.Description)
+		if ld.publisher() == "" {
+			ld.Publisher = o.Publisher
+		}
+		if ld.image() == "" {
+			ld.Image = o.Image
+		}
+	}
+}
+
+// title returns the headline, or the name when there is no headline.
+func (ld *jsonLD) title() string {
+	return first(ld.Headline, ld.Name)
+}
+
+// publisher returns the name of the publisher, which can be a string or
+// an Organization.
+func (ld *jsonLD) publisher() string {
+	if len(ld.Publisher) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(ld.Publisher, &s) == nil {
+		return s
+	}
+	var o struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(ld.Publisher, &o) == nil {
+		return o.Name
+	}
+	return ""
+}
+
+// image returns the URL of the image, which can be a string, an
+// ImageObject or an array of either.
+func (ld *jsonLD) image() string {
+	return jsonLDURL(ld.Image)
+}
+
+// jsonLDURL returns the URL of a JSON-LD value that is a string, an object
+// with a url or an array of those.
+func jsonLDURL(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var o struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal(raw, &o) == nil {
+		return o.URL
+	}
+	var a []json.RawMessage
+	if json.Unmarshal(raw, &a) == nil {
+		for _, v := range a {
+			if u := jsonLDURL(v); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// text reads the text until the end tag with the given name. The text of
+// an element can be split over multiple tokens, which are joined. Comments
+// are dropped.
+func text(z *html.Tokenizer, tag string) string {
+	var b strings.Builder
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return stripComments(b.String())
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == tag {
+				return stripComments(b.String())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
+
+// stripComments removes HTML comments. The content of a <title> is raw
+// text, so comments in it are not tokenized as comments.
+func stripComments(s string) string {
+	for {
+		start := strings.Index(s, "<!--")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "-->")
+		if end < 0 {
+			return s[:start]
+		}
+		s = s[:start] + " " + s[start+end+len("-->"):]
+	}
+}
+
+// cleanText collapses whitespace. Entities are already decoded by the
+// tokenizer, text that is escaped twice is kept as the page shows it.
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// attr returns the value of the attribute with the given key.
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// first returns the first non-empty value.
+func first(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolve resolves ref against base. It returns nil when ref is invalid.
+func resolve(base *url.URL, ref string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil
+	}
+	if base == nil {
+		return u
+	}
+	return base.ResolveReference(u)
+}
+
+// urlString returns the string of u, or an empty string when u is nil.
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
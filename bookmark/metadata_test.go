@@ -0,0 +1,290 @@
+package bookmark_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const htmlContent = `
+<!DOCTYPE html>
+<html>
+<head>
+	<title>Example Domain</title>
+</head>
+<body>
+	<div>
+		<h1>webpage</h1>
+	</div>
+</body>
+</html>
+`
+
+func TestFetchTitle(t *testing.T) {
+	t.Run("Successfully fetch webpage", func(t *testing.T) {
+		// Create a test server that serves the mock HTML
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(htmlContent))
+		}))
+		defer server.Close()
+
+		// Use the test server's URL instead of an actual URL
+		client := &http.Client{}
+		title, err := bookmark.FetchTitle(t.Context(), client, server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectedTitle := "Example Domain"
+		if title != expectedTitle {
+			t.Errorf("expected title %q, got %q", expectedTitle, title)
+		}
+	})
+}
+
+func TestExtractMetadata(t *testing.T) {
+	pageURL, err := url.Parse("https://example.com/blog/post?id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		html string
+		want *bookmark.Metadata
+	}{
+		{
+			name: "open graph is preferred",
+			html: `<html lang="en-US"><head>
+				<title>Post | Example</title>
+				<meta property="og:title" content="Post">
+				<meta property="og:description" content="An open graph description">
+				<meta name="description" content="A meta description">
+				<meta property="og:site_name" content="Example Blog">
+				<meta property="og:url" content="https://example.com/blog/post">
+				<meta property="og:image" content="/images/post.png">
+				<link rel="icon" href="/static/icon.svg">
+			</head></html>`,
+			want: &bookmark.Metadata{
+				Title:        "Post",
+				Description:  "An open graph description",
+				SiteName:     "Example Blog",
+				CanonicalURL: "https://example.com/blog/post",
+				FaviconURL:   "https://example.com/static/icon.svg",
+				ImageURL:     "https://example.com/images/post.png",
+				Language:     "en-US",
+			},
+		},
+		{
+			name: "fallbacks",
+			html: `<html><head>
+				<title> Post | Example </title>
+				<meta name="twitter:description" content="A twitter description">
+				<meta name="description" content="A meta description">
+				<meta property="og:locale" content="nl_NL">
+				<link rel="canonical" href="post">
+			</head></html>`,
+			want: &bookmark.Metadata{
+				Title:        "Post | Example",
+				Description:  "A twitter description",
+				SiteName:     "example.com",
+				CanonicalURL: "https://example.com/blog/post",
+				FaviconURL:   "https://example.com/favicon.ico",
+				Language:     "nl-NL",
+			},
+		},
+		{
+			name: "json-ld",
+			html: `<html><head>
+				<script type="application/ld+json">
+				{"@context": "https://schema.org", "@graph": [
+					{"@type": "WebSite", "name": "Example"},
+					{"@type": "Article", "headline": "A headline", "description": "A JSON-LD description",
+					 "image": [{"@type": "ImageObject", "url": "https://cdn.example.com/a.jpg"}],
+					 "publisher": {"@type": "Organization", "name": "Example Inc."}}
+				]}
+				</script>
+			</head></html>`,
+			want: &bookmark.Metadata{
+				Title:        "A headline",
+				Description:  "A JSON-LD description",
+				SiteName:     "Example Inc.",
+				CanonicalURL: "https://example.com/blog/post?id=1",
+				FaviconURL:   "https://example.com/favicon.ico",
+				ImageURL:     "https://cdn.example.com/a.jpg",
+			},
+		},
+		{
+			name: "base href",
+			html: `<html><head>
+				<base href="https://static.example.com/">
+				<link rel="shortcut icon" href="favicon.png">
+			</head></html>`,
+			want: &bookmark.Metadata{
+				SiteName:     "example.com",
+				CanonicalURL: "https://example.com/blog/post?id=1",
+				FaviconURL:   "https://static.example.com/favicon.png",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bookmark.ExtractMetadata(strings.NewReader(tt.html), pageURL)
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ExtractMetadata() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchMetadata(t *testing.T) {
+	t.Run("relative urls are resolved against the url after redirects", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/new/page", http.StatusMovedPermanently)
+		})
+		mux.HandleFunc("/new/page", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<title>Page</title><meta property="og:image" content="image.png">`))
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		md, err := bookmark.FetchMetadata(t.Context(), &http.Client{}, server.URL+"/old")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := server.URL + "/new/image.png"; md.ImageURL != want {
+			t.Errorf("expected image url %q, got %q", want, md.ImageURL)
+		}
+		if want := server.URL + "/new/page"; md.CanonicalURL != want {
+			t.Errorf("expected canonical url %q, got %q", want, md.CanonicalURL)
+		}
+	})
+
+	t.Run("the text of the main content is extracted", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<title>Page</title><nav><a href="/">Home</a></nav>` +
+				`<article><p>Operators reconcile the custom resources of a cluster.</p></article>`))
+		}))
+		defer server.Close()
+
+		md, err := bookmark.FetchMetadata(t.Context(), &http.Client{}, server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "Operators reconcile the custom resources of a cluster.\n"; md.Text != want {
+			t.Errorf("expected text %q, got %q", want, md.Text)
+		}
+		if md.Title != "Page" {
+			t.Errorf("expected title %q, got %q", "Page", md.Title)
+		}
+	})
+
+	t.Run("non 200 responses fail", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		if _, err := bookmark.FetchMetadata(t.Context(), &http.Client{}, server.URL); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestFetchMetadata_charset(t *testing.T) {
+	encode := func(t *testing.T, e encoding.Encoding, s string) []byte {
+		t.Helper()
+		b, err := e.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		return b
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{
+			name:        "shift-jis from content-type header",
+			contentType: "text/html; charset=Shift_JIS",
+			body:        encode(t, japanese.ShiftJIS, `<title>日本語のタイトル</title>`),
+			want:        "日本語のタイトル",
+		},
+		{
+			name:        "windows-1252 from meta charset",
+			contentType: "text/html",
+			body:        encode(t, charmap.Windows1252, `<meta charset="windows-1252"><title>Café “quoted”</title>`),
+			want:        "Café “quoted”",
+		},
+		{
+			name:        "iso-8859-1 from meta http-equiv",
+			contentType: "text/html",
+			body: encode(t, charmap.ISO8859_1,
+				`<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><title>Über uns</title>`),
+			want: "Über uns",
+		},
+		{
+			name:        "utf-16 from byte order mark",
+			contentType: "text/html",
+			body:        encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), `<title>Ünïcödé</title>`),
+			want:        "Ünïcödé",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(tt.body)
+			}))
+			defer server.Close()
+			md, err := bookmark.FetchMetadata(t.Context(), &http.Client{}, server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if md.Title != tt.want {
+				t.Errorf("expected title %q, got %q", tt.want, md.Title)
+			}
+		})
+	}
+}
+
+func TestExtractMetadata_title(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "entities", html: `<title>Tom &amp; Jerry &#8211; &quot;Cartoons&quot;</title>`, want: `Tom & Jerry – "Cartoons"`},
+		{name: "escaped twice", html: `<title>Use &amp;lt;b&amp;gt; tags</title>`, want: "Use &lt;b&gt; tags"},
+		{name: "whitespace", html: "<title>\n  A title\n\tspanning   lines\n</title>", want: "A title spanning lines"},
+		{name: "comments", html: `<title>A <!-- hidden --> title</title>`, want: "A title"},
+		{name: "meta entities", html: `<meta property="og:title" content="Q&amp;A  session">`, want: "Q&A session"},
+		{name: "meta escaped twice", html: `<meta property="og:title" content="x &amp;amp; y">`, want: "x &amp; y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := bookmark.ExtractMetadata(strings.NewReader(tt.html), nil)
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if md.Title != tt.want {
+				t.Errorf("expected title %q, got %q", tt.want, md.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package netscape reads bookmark files in the Netscape bookmark format,
+// the bookmarks.html format that every browser can export.
+package netscape
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Entry is a bookmark in a Netscape bookmark file.
+type Entry struct {
+	Title string
+	URL   string
+	// AddDate is the zero time when the entry has no valid ADD_DATE.
+	AddDate time.Time
+	// Folders is the path of folders the entry is in, outermost first.
+	Folders []string
+	// Tags are the tags of the TAGS attribute, as written by Firefox.
+	Tags []string
+}
+
+// Parse parses a Netscape bookmark file. Entries are returned in the order
+// they appear in the file.
+func Parse(r io.Reader) ([]*Entry, error) {
+	p := &parser{z: html.NewTokenizer(r)}
+	return p.parse()
+}
+
+// parser keeps track of the folder structure while tokenizing.
+type parser struct {
+	z       *html.Tokenizer
+	entries []*Entry
+	// folders is the stack of open DL lists. The root list and lists
+	// without a heading have an empty name.
+	folders []string
+	// heading is the name of the last folder heading, it names the next
+	// DL list.
+	heading string
+}
+
+func (p *parser) parse() ([]*Entry, error) {
+	for {
+		tt := p.z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if errors.Is(p.z.Err(), io.EOF) {
+				return p.entries, nil
+			}
+			return nil, p.z.Err()
+		case html.StartTagToken:
+			tok := p.z.Token()
+			switch tok.Data {
+			case "h3":
+				p.heading = p.text("h3")
+			case "dl":
+				p.folders = append(p.folders, p.heading)
+				p.heading = ""
+			case "a":
+				p.entries = append(p.entries, p.entry(tok))
+			}
+		case html.EndTagToken:
+			if tok := p.z.Token(); tok.Data == "dl" && len(p.folders) > 0 {
+				p.folders = p.folders[:len(p.folders)-1]
+			}
+		case html.TextToken, html.SelfClosingTagToken, html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
+
+// entry creates an entry from an A tag and its text.
+func (p *parser) entry(tok html.Token) *Entry {
+	e := &Entry{Folders: p.path()}
+	for _, a := range tok.Attr {
+		switch a.Key {
+		case "href":
+			e.URL = strings.TrimSpace(a.Val)
+		case "add_date":
+			e.AddDate = parseDate(a.Val)
+		case "tags":
+			for _, t := range strings.Split(a.Val, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					e.Tags = append(e.Tags, t)
+				}
+			}
+		}
+	}
+	e.Title = p.text("a")
+	return e
+}
+
+// path returns the names of the open folders, skipping unnamed lists.
+func (p *parser) path() []string {
+	var path []string
+	for _, f := range p.folders {
+		if f != "" {
+			path = append(path, f)
+		}
+	}
+	return path
+}
+
+// text reads the text until the end tag with the given name.
+func (p *parser) text(tag string) string {
+	var b strings.Builder
+	for {
+		switch p.z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(p.z.Text())
+		case html.EndTagToken:
+			if name, _ := p.z.TagName(); string(name) == tag {
+				return strings.TrimSpace(b.String())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.CommentToken, html.DoctypeToken:
+			continue
+		}
+	}
+}
+
+// parseDate parses an ADD_DATE attribute. Browsers write seconds since the
+// epoch, but some tools write milliseconds or microseconds.
+func parseDate(s string) time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case n > 1e14: // microseconds
+		return time.UnixMicro(n).UTC()
+	case n > 1e11: // milliseconds
+		return time.UnixMilli(n).UTC()
+	default:
+		return time.Unix(n, 0).UTC()
+	}
+}
@@ -0,0 +1,116 @@
+package netscape_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark/netscape"
+	"github.com/google/go-cmp/cmp"
+)
+
+const bookmarksHTML = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000001">The Go Programming Language</A>
+        <DT><H3>Docs</H3>
+        <DL><p>
+            <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1700000002000" TAGS="go,reference">Go Packages &amp; Modules</A>
+        </DL><p>
+    </DL><p>
+    <DT><A HREF="https://example.com/" ADD_DATE="invalid">Example</A>
+</DL><p>
+`
+
+func TestParse(t *testing.T) {
+	got, err := netscape.Parse(strings.NewReader(bookmarksHTML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []*netscape.Entry{
+		{
+			Title:   "The Go Programming Language",
+			URL:     "https://go.dev/",
+			AddDate: time.Unix(1700000001, 0).UTC(),
+			Folders: []string{"Bookmarks bar"},
+		},
+		{
+			Title:   "Go Packages & Modules",
+			URL:     "https://pkg.go.dev/",
+			AddDate: time.UnixMilli(1700000002000).UTC(),
+			Folders: []string{"Bookmarks bar", "Docs"},
+			Tags:    []string{"go", "reference"},
+		},
+		{
+			Title: "Example",
+			URL:   "https://example.com/",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := []*netscape.Entry{
+		{
+			Title:   "Go Packages & Modules",
+			URL:     "https://pkg.go.dev/",
+			AddDate: time.Unix(1700000002, 0).UTC(),
+			Folders: []string{"Bookmarks bar", "Docs"},
+			Tags:    []string{"go", "reference"},
+		},
+		{
+			Title:   "The Go Programming Language",
+			URL:     "https://go.dev/",
+			AddDate: time.Unix(1700000001, 0).UTC(),
+			Folders: []string{"Bookmarks bar"},
+		},
+		{
+			Title: "Example",
+			URL:   "https://example.com/",
+		},
+	}
+	var b strings.Builder
+	if err := netscape.Write(&b, entries); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://example.com/">Example</A>
+    <DT><H3>Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000001">The Go Programming Language</A>
+        <DT><H3>Docs</H3>
+        <DL><p>
+            <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1700000002" TAGS="go,reference">Go Packages &amp; Modules</A>
+        </DL><p>
+    </DL><p>
+</DL><p>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+	}
+
+	// a written file should parse back to the same entries
+	got, err := netscape.Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Parse() returned %d entries, want %d", len(got), len(entries))
+	}
+}
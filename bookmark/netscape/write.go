@@ -0,0 +1,77 @@
+package netscape
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// header is written at the start of every bookmark file. Browsers expect
+// exactly this doctype and the charset declaration.
+const header = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+`
+
+// folder is a node in the folder tree that is written.
+type folder struct {
+	name    string
+	entries []*Entry
+	folders []*folder
+}
+
+// child returns the subfolder with the given name, creating it if needed.
+func (f *folder) child(name string) *folder {
+	for _, c := range f.folders {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &folder{name: name}
+	f.folders = append(f.folders, c)
+	return c
+}
+
+// Write writes the entries as a Netscape bookmark file that browsers can
+// import. Entries are nested in folders according to their Folders.
+func Write(w io.Writer, entries []*Entry) error {
+	root := &folder{}
+	for _, e := range entries {
+		f := root
+		for _, name := range e.Folders {
+			f = f.child(name)
+		}
+		f.entries = append(f.entries, e)
+	}
+	var b strings.Builder
+	b.WriteString(header)
+	writeFolder(&b, root, 0)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFolder writes the DL list of a folder.
+func writeFolder(b *strings.Builder, f *folder, depth int) {
+	indent := strings.Repeat("    ", depth)
+	b.WriteString(indent + "<DL><p>\n")
+	for _, e := range f.entries {
+		b.WriteString(indent + "    <DT><A HREF=\"" + html.EscapeString(e.URL) + "\"")
+		if !e.AddDate.IsZero() {
+			fmt.Fprintf(b, " ADD_DATE=\"%d\"", e.AddDate.Unix())
+		}
+		if len(e.Tags) > 0 {
+			b.WriteString(" TAGS=\"" + html.EscapeString(strings.Join(e.Tags, ",")) + "\"")
+		}
+		b.WriteString(">" + html.EscapeString(e.Title) + "</A>\n")
+	}
+	for _, c := range f.folders {
+		b.WriteString(indent + "    <DT><H3>" + html.EscapeString(c.name) + "</H3>\n")
+		writeFolder(b, c, depth+1)
+	}
+	b.WriteString(indent + "</DL><p>\n")
+}
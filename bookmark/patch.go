@@ -0,0 +1,65 @@
+package bookmark
+
+import (
+	"slices"
+	"time"
+)
+
+// Patch is a partial update of a bookmark. Only the fields that are not nil
+// are applied.
+type Patch struct {
+	Title   *string
+	Content *string
+	// Tags replaces all tags of the bookmark.
+	Tags *[]string
+	// AddTags and RemoveTags add and remove individual tags. They are
+	// applied after Tags.
+	AddTags    []string
+	RemoveTags []string
+	// Collection moves the bookmark to the collection with this path.
+	Collection *string
+	Metadata   *Metadata
+	// PendingMetadata sets or clears the pending metadata flag.
+	PendingMetadata *bool
+	Health          *Health
+	Snapshot        *Snapshot
+	UpdatedAt       time.Time
+}
+
+// Apply applies the patch to the given bookmark.
+func (p *Patch) Apply(b *Bookmark) {
+	if p.Title != nil {
+		b.Title = *p.Title
+	}
+	if p.Content != nil {
+		b.Content = *p.Content
+	}
+	if p.Tags != nil {
+		b.Tags = NormalizeTags(*p.Tags)
+	}
+	if len(p.AddTags) > 0 {
+		b.Tags = NormalizeTags(append(slices.Clone(b.Tags), p.AddTags...))
+	}
+	if len(p.RemoveTags) > 0 {
+		remove := NormalizeTags(p.RemoveTags)
+		b.Tags = NormalizeTags(slices.DeleteFunc(slices.Clone(b.Tags), func(t string) bool {
+			return slices.Contains(remove, t)
+		}))
+	}
+	if p.Collection != nil {
+		b.Collection = NormalizeCollection(*p.Collection)
+	}
+	if p.Metadata != nil {
+		b.Metadata = *p.Metadata
+	}
+	if p.PendingMetadata != nil {
+		b.PendingMetadata = *p.PendingMetadata
+	}
+	if p.Health != nil {
+		b.Health = *p.Health
+	}
+	if p.Snapshot != nil {
+		b.Snapshot = *p.Snapshot
+	}
+	b.UpdatedAt = p.UpdatedAt
+}
@@ -0,0 +1,89 @@
+package bookmark_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPatch_Apply(t *testing.T) {
+	title := "New title"
+	content := "https://example.com"
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		patch *bookmark.Patch
+		want  *bookmark.Bookmark
+	}{
+		{
+			name:  "empty patch only sets updated at",
+			patch: &bookmark.Patch{UpdatedAt: updated},
+			want: &bookmark.Bookmark{
+				ID:        "1",
+				Title:     "Old title",
+				Content:   "https://example.org",
+				Tags:      []string{"a"},
+				CreatedAt: created,
+				UpdatedAt: updated,
+			},
+		},
+		{
+			name:  "patch title and content",
+			patch: &bookmark.Patch{Title: &title, Content: &content, UpdatedAt: updated},
+			want: &bookmark.Bookmark{
+				ID:        "1",
+				Title:     title,
+				Content:   content,
+				Tags:      []string{"a"},
+				CreatedAt: created,
+				UpdatedAt: updated,
+			},
+		},
+		{
+			name: "replace, add and remove tags",
+			patch: &bookmark.Patch{
+				Tags:       &[]string{"b", "c"},
+				AddTags:    []string{"D"},
+				RemoveTags: []string{"c"},
+				UpdatedAt:  updated,
+			},
+			want: &bookmark.Bookmark{
+				ID:        "1",
+				Title:     "Old title",
+				Content:   "https://example.org",
+				Tags:      []string{"b", "d"},
+				CreatedAt: created,
+				UpdatedAt: updated,
+			},
+		},
+		{
+			name:  "remove the last tag",
+			patch: &bookmark.Patch{RemoveTags: []string{"a"}, UpdatedAt: updated},
+			want: &bookmark.Bookmark{
+				ID:        "1",
+				Title:     "Old title",
+				Content:   "https://example.org",
+				CreatedAt: created,
+				UpdatedAt: updated,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bookmark.Bookmark{
+				ID:        "1",
+				Title:     "Old title",
+				Content:   "https://example.org",
+				Tags:      []string{"a"},
+				CreatedAt: created,
+			}
+			tt.patch.Apply(b)
+			if diff := cmp.Diff(tt.want, b); diff != "" {
+				t.Errorf("Patch.Apply() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
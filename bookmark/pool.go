@@ -0,0 +1,25 @@
+package bookmark
+
+import "sync"
+
+// forEach calls fn for the indexes 0 to n-1 from at most workers goroutines
+// at once and waits for all calls to return.
+func forEach(n, workers int, fn func(i int)) {
+	workers = max(1, min(workers, n))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
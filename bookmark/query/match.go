@@ -0,0 +1,146 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// Mode is how words and phrases match text. Matching always ignores case.
+type Mode string
+
+// Modes of matching.
+const (
+	// ModeSubstring matches text that contains the value.
+	ModeSubstring Mode = "substring"
+	// ModeFuzzy matches text that contains the characters of the value in
+	// order, like "gthb" in "GitHub". Phrases match as substrings. The page
+	// text is not searched, as most pages contain the characters of a word
+	// somewhere.
+	ModeFuzzy Mode = "fuzzy"
+	// ModeRegex matches text with the value as a regular expression. Values
+	// with spaces or parentheses must be quoted.
+	ModeRegex Mode = "regex"
+)
+
+// Modes are all modes of matching.
+//
+//nolint:gochecknoglobals // static list of modes
+var Modes = []Mode{ModeSubstring, ModeFuzzy, ModeRegex}
+
+// ParseMode returns the mode with the given name.
+func ParseMode(name string) (Mode, error) {
+	if m := Mode(name); slices.Contains(Modes, m) {
+		return m, nil
+	}
+	names := make([]string, 0, len(Modes))
+	for _, m := range Modes {
+		names = append(names, string(m))
+	}
+	return "", fmt.Errorf("unknown search mode %q, use one of %s", name, strings.Join(names, ", "))
+}
+
+// Range is a part of a text from Start up to End, in bytes.
+type Range struct {
+	Start, End int
+}
+
+// Match is where a term matches a text.
+type Match struct {
+	// Score is how well the term matches, from 0 to 1. Matches that start at
+	// a word, cover more of the text and have their characters close
+	// together score higher.
+	Score float64
+	// Ranges are the matched parts of the text, in order.
+	Ranges []Range
+}
+
+// regexps caches the expressions that terms compile to, so matching many
+// texts compiles a term once.
+//
+//nolint:gochecknoglobals // a sync.Map is safe for concurrent use
+var regexps sync.Map
+
+// Match returns where the value of the term matches the text in the mode of
+// the term, and false when it does not match. Terms without a mode match
+// substrings.
+func (t *Term) Match(text string) (Match, bool) {
+	if t.Mode == ModeFuzzy {
+		return matchFuzzy(text, t.Value)
+	}
+	re, err := t.regexp()
+	if err != nil {
+		return Match{}, false
+	}
+	var m Match
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		r := Range{Start: loc[0], End: loc[1]}
+		m.Ranges = append(m.Ranges, r)
+		m.Score = max(m.Score, score(text, []Range{r}))
+	}
+	return m, len(m.Ranges) > 0
+}
+
+// regexp returns the expression that matches the value of a substring or
+// regex term, ignoring case.
+func (t *Term) regexp() (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(t.Value)
+	if t.Mode == ModeRegex {
+		expr = t.Value
+	}
+	expr = "(?i)" + expr
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil //nolint:errcheck // only expressions are stored
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+	return re, nil
+}
+
+// matchFuzzy matches the characters of the pattern in order.
+func matchFuzzy(text, pattern string) (Match, bool) {
+	matches := fuzzy.FindNoSort(pattern, []string{text})
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	var m Match
+	for _, i := range matches[0].MatchedIndexes {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		if n := len(m.Ranges); n > 0 && m.Ranges[n-1].End == i {
+			m.Ranges[n-1].End += size
+			continue
+		}
+		m.Ranges = append(m.Ranges, Range{Start: i, End: i + size})
+	}
+	m.Score = score(text, m.Ranges)
+	return m, true
+}
+
+// score rates a single match of the ranges in the text from 0 to 1.
+func score(text string, ranges []Range) float64 {
+	first, last := ranges[0], ranges[len(ranges)-1]
+	var matched int
+	for _, r := range ranges {
+		matched += r.End - r.Start
+	}
+	compact := float64(matched) / float64(last.End-first.Start)
+	cover := float64(matched) / float64(len(text))
+	var word float64
+	if before, _ := utf8.DecodeLastRuneInString(text[:first.Start]); first.Start == 0 ||
+		!unicode.IsLetter(before) && !unicode.IsDigit(before) {
+		word = 1
+	}
+	return (2*compact + word + cover) / 4
+}
@@ -0,0 +1,115 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTerm_Match(t *testing.T) {
+	tests := []struct {
+		name       string
+		term       query.Term
+		text       string
+		wantRanges []query.Range
+	}{
+		{
+			name:       "substring ignores case",
+			term:       query.Term{Value: "github", Mode: query.ModeSubstring},
+			text:       "GitHub - foo",
+			wantRanges: []query.Range{{Start: 0, End: 6}},
+		},
+		{
+			name:       "substring finds every occurrence",
+			term:       query.Term{Value: "go"},
+			text:       "Go, go, gone",
+			wantRanges: []query.Range{{Start: 0, End: 2}, {Start: 4, End: 6}, {Start: 8, End: 10}},
+		},
+		{
+			name:       "substring is not a pattern",
+			term:       query.Term{Value: "a.c", Mode: query.ModeSubstring},
+			text:       "abc",
+			wantRanges: nil,
+		},
+		{
+			name:       "fuzzy matches characters in order",
+			term:       query.Term{Value: "gthb", Mode: query.ModeFuzzy},
+			text:       "GitHub - foo",
+			wantRanges: []query.Range{{Start: 0, End: 1}, {Start: 2, End: 4}, {Start: 5, End: 6}},
+		},
+		{
+			name:       "fuzzy ranges are in bytes",
+			term:       query.Term{Value: "cf", Mode: query.ModeFuzzy},
+			text:       "café fika",
+			wantRanges: []query.Range{{Start: 0, End: 1}, {Start: 6, End: 7}},
+		},
+		{
+			name:       "fuzzy needs all characters",
+			term:       query.Term{Value: "gthbz", Mode: query.ModeFuzzy},
+			text:       "GitHub - foo",
+			wantRanges: nil,
+		},
+		{
+			name:       "regex ignores case",
+			term:       query.Term{Value: `go(lang)?\b`, Mode: query.ModeRegex},
+			text:       "Golang and go",
+			wantRanges: []query.Range{{Start: 0, End: 6}, {Start: 11, End: 13}},
+		},
+		{
+			name:       "invalid regex does not match",
+			term:       query.Term{Value: `(go`, Mode: query.ModeRegex},
+			text:       "(go",
+			wantRanges: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.term.Match(tt.text)
+			if ok != (tt.wantRanges != nil) {
+				t.Fatalf("Term.Match() ok = %v, want %v", ok, tt.wantRanges != nil)
+			}
+			if diff := cmp.Diff(tt.wantRanges, got.Ranges); diff != "" {
+				t.Errorf("Term.Match() mismatch (-want +got):\n%s", diff)
+			}
+			if ok && (got.Score <= 0 || got.Score > 1) {
+				t.Errorf("Term.Match() score = %v, want a score from 0 to 1", got.Score)
+			}
+		})
+	}
+
+	t.Run("match should score better matches higher", func(t *testing.T) {
+		for _, mode := range query.Modes {
+			term := query.Term{Value: "hub", Mode: mode}
+			// a whole word beats the start of a word, which beats the
+			// middle of one
+			var scores []float64
+			for _, text := range []string{"hub", "hubs and more", "github and more"} {
+				m, ok := term.Match(text)
+				if !ok {
+					t.Fatalf("Term.Match(%q) in %s mode does not match", text, mode)
+				}
+				scores = append(scores, m.Score)
+			}
+			if scores[0] <= scores[1] || scores[1] <= scores[2] {
+				t.Errorf("Term.Match() in %s mode scores = %v, want them decreasing", mode, scores)
+			}
+		}
+	})
+}
+
+func TestParseMode(t *testing.T) {
+	t.Run("parse mode should accept every mode", func(t *testing.T) {
+		for _, m := range query.Modes {
+			if got, err := query.ParseMode(string(m)); err != nil || got != m {
+				t.Errorf("ParseMode(%q) = %q, %v", m, got, err)
+			}
+		}
+	})
+
+	t.Run("parse mode should reject unknown modes", func(t *testing.T) {
+		if _, err := query.ParseMode("exact"); err == nil {
+			t.Error("ParseMode() error = nil, want an error")
+		}
+	})
+}
@@ -0,0 +1,396 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is a syntax error in a query.
+type Error struct {
+	// Column is the position of the error in the query, in characters
+	// starting at 1.
+	Column int
+	Msg    string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// errorf returns an *Error at the given column.
+func errorf(column int, format string, args ...any) *Error {
+	return &Error{Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+//nolint:gochecknoglobals // static list of fields
+var fields = []Field{FieldTitle, FieldURL, FieldSite, FieldTag, FieldCreated, FieldUpdated}
+
+// Parse parses a query whose words and phrases match in the given mode.
+// Relative dates like last-week are relative to now. An empty query parses
+// to a nil expression, which matches everything. Errors are of type *Error.
+func Parse(query string, mode Mode, now time.Time) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, mode: mode, now: now}
+	if p.peek().kind == tokenEOF {
+		return nil, nil
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errorf(t.column, "unexpected %s", t)
+	}
+	return e, nil
+}
+
+// tokenKind is the kind of a token.
+type tokenKind int
+
+// Kinds of tokens.
+const (
+	tokenEOF tokenKind = iota
+	tokenOpen
+	tokenClose
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+// token is a token of a query.
+type token struct {
+	kind   tokenKind
+	column int
+	// term and valueColumn are set for terms.
+	term        Term
+	valueColumn int
+}
+
+// String returns how the token is described in errors.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenOpen:
+		return `"("`
+	case tokenClose:
+		return `")"`
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	default:
+		return fmt.Sprintf("%q", t.term.Value)
+	}
+}
+
+// lex splits a query into tokens. The last token is always tokenEOF.
+func lex(query string) ([]token, error) {
+	runes := []rune(query)
+	var tokens []token
+	i := 0
+	for {
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+		column := i + 1
+		if i == len(runes) {
+			return append(tokens, token{kind: tokenEOF, column: column}), nil
+		}
+		switch r := runes[i]; {
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, column: column})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, column: column})
+			i++
+		case r == '-' && i+1 < len(runes) && !isDelimiter(runes[i+1]):
+			tokens = append(tokens, token{kind: tokenNot, column: column})
+			i++
+		default:
+			t, next, err := lexTerm(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = next
+		}
+	}
+}
+
+// lexTerm reads the term or keyword that starts at i. It returns the
+// token and the position after it.
+func lexTerm(runes []rune, i int) (token, int, error) {
+	t := token{kind: tokenTerm, column: i + 1, valueColumn: i + 1}
+	// a field name is only special when it is known, so URLs are words
+	word := slices.IndexFunc(runes[i:], isDelimiter)
+	if word < 0 {
+		word = len(runes) - i
+	}
+	if colon := slices.Index(runes[i:i+word], ':'); colon > 0 {
+		name := Field(strings.ToLower(string(runes[i : i+colon])))
+		if slices.Contains(fields, name) {
+			t.term.Field = name
+			i += colon + 1
+			t.valueColumn = i + 1
+			if i == len(runes) || (isDelimiter(runes[i]) && runes[i] != '"') {
+				return token{}, 0, errorf(t.valueColumn, "missing value for %s", name)
+			}
+		}
+	}
+	if runes[i] == '"' {
+		end := slices.Index(runes[i+1:], '"')
+		if end < 0 {
+			return token{}, 0, errorf(i+1, "missing closing quote")
+		}
+		t.term.Value = string(runes[i+1 : i+1+end])
+		t.term.Phrase = true
+		if strings.TrimSpace(t.term.Value) == "" {
+			return token{}, 0, errorf(i+1, "empty phrase")
+		}
+		return t, i + end + 2, nil
+	}
+	start := i
+	for i < len(runes) && !isDelimiter(runes[i]) {
+		i++
+	}
+	t.term.Value = string(runes[start:i])
+	if t.term.Field == FieldText {
+		switch t.term.Value {
+		case "AND":
+			t.kind = tokenAnd
+		case "OR":
+			t.kind = tokenOr
+		case "NOT":
+			t.kind = tokenNot
+		}
+	}
+	return t, i, nil
+}
+
+// isDelimiter reports whether a rune ends a word.
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+// parser is a recursive descent parser of the grammar:
+//
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }
+//	unary   = ( "NOT" | "-" ) unary | primary
+//	primary = "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+	mode   Mode
+	now    time.Time
+}
+
+// peek returns the next token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes the next token.
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenTerm, tokenNot, tokenOpen:
+			// terms next to each other must all match
+		default:
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.peek().kind != tokenNot {
+		return p.primary()
+	}
+	p.next()
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &Not{Expr: e}, nil
+}
+
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenOpen:
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			return nil, errorf(t.column, "missing closing parenthesis")
+		}
+		p.next()
+		return e, nil
+	case tokenTerm:
+		switch t.term.Field {
+		case FieldCreated, FieldUpdated:
+			return p.dateRange(t)
+		case FieldText, FieldTitle, FieldURL:
+			return p.text(t)
+		default:
+			term := t.term
+			return &term, nil
+		}
+	default:
+		return nil, errorf(t.column, "expected a term, got %s", t)
+	}
+}
+
+// text returns the term of a word, phrase or text field in the mode of the
+// parser.
+func (p *parser) text(t token) (Expr, error) {
+	term := t.term
+	term.Mode = p.mode
+	switch {
+	case p.mode == ModeFuzzy && term.Phrase:
+		term.Mode = ModeSubstring
+	case p.mode == ModeRegex:
+		if _, err := regexp.Compile(term.Value); err != nil {
+			column := t.valueColumn
+			if term.Phrase {
+				column++
+			}
+			msg := strings.TrimPrefix(err.Error(), "error parsing regexp: ")
+			return nil, errorf(column, "invalid regular expression: %s", msg)
+		}
+	}
+	return &term, nil
+}
+
+// dateRange parses the value of a date term. A value is a date or a range
+// of dates, optionally prefixed by a comparison.
+func (p *parser) dateRange(t token) (Expr, error) {
+	value := t.term.Value
+	var op string
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(value, o); ok {
+			op, value = o, rest
+			break
+		}
+	}
+	column := t.valueColumn + utf8.RuneCountInString(op)
+	var from, to time.Time
+	if a, b, ok := strings.Cut(value, ".."); ok && op == "" {
+		if a != "" {
+			f, _, err := p.date(a, column)
+			if err != nil {
+				return nil, err
+			}
+			from = f
+		}
+		if b != "" {
+			_, end, err := p.date(b, column+utf8.RuneCountInString(a)+len(".."))
+			if err != nil {
+				return nil, err
+			}
+			to = end
+		}
+		return &DateRange{Field: t.term.Field, From: from, To: to}, nil
+	}
+	start, end, err := p.date(value, column)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "", "=":
+		from, to = start, end
+	case ">":
+		from = end
+	case ">=":
+		from = start
+	case "<":
+		to = start
+	case "<=":
+		to = end
+	}
+	return &DateRange{Field: t.term.Field, From: from, To: to}, nil
+}
+
+// date returns the start and end of the period that a date value stands
+// for. Periods end at the start of the next day, month or year.
+func (p *parser) date(value string, column int) (time.Time, time.Time, error) {
+	loc := p.now.Location()
+	today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+	switch value {
+	case "today":
+		return today, tomorrow, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "last-week":
+		return today.AddDate(0, 0, -7), tomorrow, nil
+	case "last-month":
+		return today.AddDate(0, -1, 0), tomorrow, nil
+	case "last-year":
+		return today.AddDate(-1, 0, 0), tomorrow, nil
+	}
+	// the period of a date is as long as its precision
+	for _, period := range []struct {
+		layout              string
+		years, months, days int
+	}{
+		{layout: time.DateOnly, days: 1},
+		{layout: "2006-01", months: 1},
+		{layout: "2006", years: 1},
+	} {
+		if t, err := time.ParseInLocation(period.layout, value, loc); err == nil {
+			return t, t.AddDate(period.years, period.months, period.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, errorf(column, "invalid date %q, use a date like 2025-01-31, "+
+		"a month, a year, today, yesterday, last-week, last-month or last-year", value)
+}
@@ -0,0 +1,218 @@
+package query_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	word := func(v string) *query.Term { return &query.Term{Value: v, Mode: query.ModeSubstring} }
+
+	tests := []struct {
+		name  string
+		query string
+		mode  query.Mode
+		want  query.Expr
+	}{
+		{name: "empty", query: "  ", want: nil},
+		{name: "word", query: "go", want: word("go")},
+		{name: "implicit and", query: "go blog", want: &query.And{Left: word("go"), Right: word("blog")}},
+		{name: "explicit and", query: "go AND blog", want: &query.And{Left: word("go"), Right: word("blog")}},
+		{
+			name:  "or binds weaker than and",
+			query: "a b OR c",
+			want:  &query.Or{Left: &query.And{Left: word("a"), Right: word("b")}, Right: word("c")},
+		},
+		{
+			name:  "parentheses",
+			query: "a (b OR c)",
+			want:  &query.And{Left: word("a"), Right: &query.Or{Left: word("b"), Right: word("c")}},
+		},
+		{name: "not", query: "NOT go", want: &query.Not{Expr: word("go")}},
+		{name: "minus", query: "-go", want: &query.Not{Expr: word("go")}},
+		{name: "lowercase keywords are words", query: "or", want: word("or")},
+		{name: "hyphenated word", query: "co-op", want: word("co-op")},
+		{
+			name:  "field",
+			query: "Title:go",
+			want:  &query.Term{Field: query.FieldTitle, Value: "go", Mode: query.ModeSubstring},
+		},
+		{name: "negated field", query: "-tag:old", want: &query.Not{Expr: &query.Term{Field: query.FieldTag, Value: "old"}}},
+		{name: "url is a word", query: "https://go.dev", want: word("https://go.dev")},
+		{name: "site", query: "site:github.com", want: &query.Term{Field: query.FieldSite, Value: "github.com"}},
+		{name: "phrase", query: `"go blog"`, want: &query.Term{Value: "go blog", Phrase: true, Mode: query.ModeSubstring}},
+		{
+			name:  "field phrase",
+			query: `title:"go blog"`,
+			want:  &query.Term{Field: query.FieldTitle, Value: "go blog", Phrase: true, Mode: query.ModeSubstring},
+		},
+		{
+			name:  "fuzzy word",
+			query: "gthb",
+			mode:  query.ModeFuzzy,
+			want:  &query.Term{Value: "gthb", Mode: query.ModeFuzzy},
+		},
+		{
+			name:  "fuzzy phrase matches substrings",
+			query: `"go blog"`,
+			mode:  query.ModeFuzzy,
+			want:  &query.Term{Value: "go blog", Phrase: true, Mode: query.ModeSubstring},
+		},
+		{
+			name:  "regex field",
+			query: `url:"^https://(www\.)?github"`,
+			mode:  query.ModeRegex,
+			want: &query.Term{
+				Field: query.FieldURL, Value: `^https://(www\.)?github`, Phrase: true, Mode: query.ModeRegex,
+			},
+		},
+		{
+			name:  "tags have no mode",
+			query: "tag:go",
+			mode:  query.ModeRegex,
+			want:  &query.Term{Field: query.FieldTag, Value: "go"},
+		},
+		{
+			name:  "day",
+			query: "created:2025-01-31",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2025, 1, 31), To: day(2025, 2, 1)},
+		},
+		{
+			name:  "after day",
+			query: "created:>2025-01-01",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2025, 1, 2)},
+		},
+		{
+			name:  "from month",
+			query: "updated:>=2025-02",
+			want:  &query.DateRange{Field: query.FieldUpdated, From: day(2025, 2, 1)},
+		},
+		{
+			name:  "before year",
+			query: "created:<2025",
+			want:  &query.DateRange{Field: query.FieldCreated, To: day(2025, 1, 1)},
+		},
+		{
+			name:  "up to year",
+			query: "created:<=2024",
+			want:  &query.DateRange{Field: query.FieldCreated, To: day(2025, 1, 1)},
+		},
+		{
+			name:  "range",
+			query: "created:2024-12..2025-01",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2024, 12, 1), To: day(2025, 2, 1)},
+		},
+		{
+			name:  "open range",
+			query: "created:2024..",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2024, 1, 1)},
+		},
+		{
+			name:  "today",
+			query: "created:today",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2025, 3, 15), To: day(2025, 3, 16)},
+		},
+		{
+			name:  "last week",
+			query: "created:last-week",
+			want:  &query.DateRange{Field: query.FieldCreated, From: day(2025, 3, 8), To: day(2025, 3, 16)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := tt.mode
+			if mode == "" {
+				mode = query.ModeSubstring
+			}
+			got, err := query.Parse(tt.query, mode, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		mode  query.Mode
+		want  *query.Error
+	}{
+		{
+			name:  "missing closing parenthesis",
+			query: "go (a OR b",
+			want:  &query.Error{Column: 4, Msg: "missing closing parenthesis"},
+		},
+		{
+			name:  "unexpected parenthesis",
+			query: "go)",
+			want:  &query.Error{Column: 3, Msg: `unexpected ")"`},
+		},
+		{
+			name:  "missing term",
+			query: "go OR",
+			want:  &query.Error{Column: 6, Msg: "expected a term, got end of query"},
+		},
+		{
+			name:  "missing closing quote",
+			query: `title:"go blog`,
+			want:  &query.Error{Column: 7, Msg: "missing closing quote"},
+		},
+		{
+			name:  "missing value",
+			query: "go tag: blog",
+			want:  &query.Error{Column: 8, Msg: "missing value for tag"},
+		},
+		{
+			name:  "empty phrase",
+			query: `go ""`,
+			want:  &query.Error{Column: 4, Msg: "empty phrase"},
+		},
+		{
+			name:  "invalid date",
+			query: "created:>=2025-13",
+			want: &query.Error{Column: 11, Msg: `invalid date "2025-13", use a date like 2025-01-31, ` +
+				"a month, a year, today, yesterday, last-week, last-month or last-year"},
+		},
+		{
+			name:  "invalid regular expression",
+			query: `go title:"(a"`,
+			mode:  query.ModeRegex,
+			want:  &query.Error{Column: 11, Msg: "invalid regular expression: missing closing ): `(a`"},
+		},
+		{
+			name:  "invalid end of range",
+			query: "created:2025..soon",
+			want: &query.Error{Column: 15, Msg: `invalid date "soon", use a date like 2025-01-31, ` +
+				"a month, a year, today, yesterday, last-week, last-month or last-year"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := tt.mode
+			if mode == "" {
+				mode = query.ModeSubstring
+			}
+			_, err := query.Parse(tt.query, mode, time.Now())
+			var got *query.Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.want)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Parse() error mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package query parses search queries into expressions.
+//
+// A query is a list of terms that must all match. Terms are words, quoted
+// phrases or filters on a field like title:go, and can be combined with
+// AND, OR, NOT and parentheses. A - before a term is short for NOT:
+//
+//	site:github.com (go OR rust) -tag:archived created:>2025-01-01
+//
+// Words and phrases match the title, URL, description, site name and page
+// text of bookmarks, ignoring case. By default they match substrings, see
+// Mode for the other ways they can match. Dates are days like 2025-01-31, months
+// like 2025-01, years, ranges like 2025-01-01..2025-03-31, or one of today,
+// yesterday, last-week, last-month and last-year. They can be compared with
+// >, >=, < and <=.
+package query
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Field is the part of a bookmark that a term matches.
+type Field string
+
+// Fields of terms.
+const (
+	// FieldText matches the title, URL, description, site name and page text.
+	FieldText Field = ""
+	// FieldTitle matches the title.
+	FieldTitle Field = "title"
+	// FieldURL matches the content, which is the URL of bookmarks of pages.
+	FieldURL Field = "url"
+	// FieldSite matches the host of the URL and its subdomains.
+	FieldSite Field = "site"
+	// FieldTag matches a tag. The value AnyTag matches bookmarks with any
+	// tag, so -tag:* matches untagged bookmarks.
+	FieldTag Field = "tag"
+	// FieldCreated matches the creation time.
+	FieldCreated Field = "created"
+	// FieldUpdated matches the time of the last update.
+	FieldUpdated Field = "updated"
+)
+
+// AnyTag is the value of a tag term that matches any tag.
+const AnyTag = "*"
+
+// Expr is an expression of a query: *And, *Or, *Not, *Term, *DateRange or
+// *IDs.
+type Expr interface {
+	expr()
+}
+
+// And matches when both expressions match.
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches when either expression matches.
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches when the expression does not match.
+type Not struct {
+	Expr Expr
+}
+
+// Term matches a value in a field.
+type Term struct {
+	Field Field
+	Value string
+	// Phrase is set for quoted values.
+	Phrase bool
+	// Mode is how the value matches text, for words, phrases and the title
+	// and url fields. Other fields leave it empty.
+	Mode Mode
+}
+
+// DateRange matches times in a field from From up to To. A zero From or To
+// leaves that side of the range open.
+type DateRange struct {
+	Field    Field
+	From, To time.Time
+}
+
+// IDs matches the bookmarks with the given IDs. It is not written in
+// queries, but replaces terms that are resolved by other means, like a
+// search index.
+type IDs struct {
+	IDs []string
+}
+
+func (*And) expr()       {}
+func (*Or) expr()        {}
+func (*Not) expr()       {}
+func (*Term) expr()      {}
+func (*DateRange) expr() {}
+func (*IDs) expr()       {}
+
+// Contains reports whether t falls in the range.
+func (r *DateRange) Contains(t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// Rewrite returns the expression with every term, date range and ID list
+// replaced by the result of fn. fn returns its argument to keep it.
+func Rewrite(e Expr, fn func(e Expr) Expr) Expr {
+	switch e := e.(type) {
+	case *And:
+		return &And{Left: Rewrite(e.Left, fn), Right: Rewrite(e.Right, fn)}
+	case *Or:
+		return &Or{Left: Rewrite(e.Left, fn), Right: Rewrite(e.Right, fn)}
+	case *Not:
+		return &Not{Expr: Rewrite(e.Expr, fn)}
+	case nil:
+		return nil
+	default:
+		return fn(e)
+	}
+}
+
+// Terms returns the terms of the expression that are not negated, in the
+// order of the query.
+func Terms(e Expr) []*Term {
+	var terms []*Term
+	var walk func(e Expr, negated bool)
+	walk = func(e Expr, negated bool) {
+		switch e := e.(type) {
+		case *And:
+			walk(e.Left, negated)
+			walk(e.Right, negated)
+		case *Or:
+			walk(e.Left, negated)
+			walk(e.Right, negated)
+		case *Not:
+			walk(e.Expr, !negated)
+		case *Term:
+			if !negated {
+				terms = append(terms, e)
+			}
+		}
+	}
+	walk(e, false)
+	return terms
+}
+
+// MatchSite reports whether the host of a URL is site or one of its
+// subdomains, ignoring case.
+func MatchSite(rawURL, site string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	site = strings.ToLower(site)
+	return host == site || strings.HasSuffix(host, "."+site)
+}
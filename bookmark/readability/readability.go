@@ -0,0 +1,210 @@
+// Package readability extracts the main text of web pages, leaving out
+// navigation, sidebars, comments and other boilerplate. It scores the
+// containers of paragraphs like Mozilla's Readability does.
+package readability
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+//nolint:gochecknoglobals // compiled once
+var (
+	// unlikely matches the class and id of elements that are rarely content.
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|` +
+		`header|menu|modal|nav|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|social|sponsor|subscribe`)
+	// maybe matches the class and id of elements that may be content even
+	// when they also match unlikely.
+	maybe = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	// positive and negative weigh the class and id of candidates.
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|story|text|blog`)
+	negative = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|contact|footer|footnote|` +
+		`masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+)
+
+const (
+	// minParagraphLength is the shortest text that counts as a paragraph.
+	minParagraphLength = 25
+	// classWeight is added or subtracted for positive or negative classes.
+	classWeight = 25
+)
+
+// Extract returns the text of the main content of a document with a line
+// per block. It falls back to all visible text of the body when no content
+// stands out. The document is not modified.
+func Extract(doc *html.Node) string {
+	skip := isUnlikely
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	score := func(n *html.Node, s float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += s
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if hidden(n.DataAtom) || skip(n) {
+				return
+			}
+			switch n.DataAtom {
+			case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+				t := strings.TrimSpace(text([]*html.Node{n}, skip))
+				if length := utf8.RuneCountInString(t); length >= minParagraphLength {
+					s := 1 + float64(strings.Count(t, ",")) + min(float64(length)/100, 3)
+					score(n.Parent, s)
+					if n.Parent != nil {
+						score(n.Parent.Parent, s/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var top *html.Node
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c, skip)
+		if top == nil || scores[c] > scores[top] {
+			top = c
+		}
+	}
+	if top == nil {
+		body := find(doc, atom.Body)
+		if body == nil {
+			body = doc
+		}
+		return text([]*html.Node{body}, skip)
+	}
+
+	// siblings that score well or hold long paragraphs belong to the content
+	threshold := max(10, scores[top]*0.2)
+	var content []*html.Node
+	for sibling := top; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling == top || belongs(sibling, scores, threshold, skip) {
+			content = append([]*html.Node{sibling}, content...)
+		}
+	}
+	for sibling := top.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if belongs(sibling, scores, threshold, skip) {
+			content = append(content, sibling)
+		}
+	}
+	return text(content, skip)
+}
+
+// belongs reports whether a sibling of the top candidate is content.
+func belongs(n *html.Node, scores map[*html.Node]float64, threshold float64, skip func(*html.Node) bool) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if s, ok := scores[n]; ok && s >= threshold {
+		return true
+	}
+	if n.DataAtom != atom.P {
+		return false
+	}
+	t := strings.TrimSpace(text([]*html.Node{n}, skip))
+	return utf8.RuneCountInString(t) > 80 && linkDensity(n, skip) < 0.25
+}
+
+// initialScore is the score of a candidate before its paragraphs count.
+func initialScore(n *html.Node) float64 {
+	var s float64
+	switch n.DataAtom {
+	case atom.Article:
+		s = 10
+	case atom.Div, atom.Main, atom.Section:
+		s = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		s = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		s = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		s = -5
+	}
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if negative.MatchString(v) {
+			s -= classWeight
+		}
+		if positive.MatchString(v) {
+			s += classWeight
+		}
+	}
+	return s
+}
+
+// isUnlikely reports whether an element is unlikely to be content.
+func isUnlikely(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Nav, atom.Aside, atom.Footer, atom.Form, atom.Button, atom.Select, atom.Dialog:
+		return true
+	case atom.Body, atom.Article, atom.Main:
+		return false
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "complementary" || role == "banner" {
+		return true
+	}
+	v := attr(n, "class") + " " + attr(n, "id")
+	return unlikely.MatchString(v) && !maybe.MatchString(v)
+}
+
+// linkDensity is the part of the text of a node that is in links.
+func linkDensity(n *html.Node, skip func(*html.Node) bool) float64 {
+	total := utf8.RuneCountInString(strings.TrimSpace(text([]*html.Node{n}, skip)))
+	if total == 0 {
+		return 0
+	}
+	var links int
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			links += utf8.RuneCountInString(strings.TrimSpace(text([]*html.Node{n}, skip)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return min(float64(links)/float64(total), 1)
+}
+
+// find returns the first element of a document with the given atom.
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// attr returns the value of an attribute of an element.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package readability_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark/readability"
+	"golang.org/x/net/html"
+)
+
+const article = `<p>Kubernetes operators extend the cluster with custom controllers, which manage complex applications.</p>
+<p>An operator watches its custom resources, compares them with the cluster, and acts to reconcile them.</p>`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "empty",
+			html: ``,
+			want: "",
+		},
+		{
+			name: "article",
+			html: `<body>
+				<nav><a href="/">Home</a><a href="/blog">Blog</a></nav>
+				<div class="sidebar"><p>Subscribe to the newsletter, it is free and it is great.</p></div>
+				<article><h1>Operators</h1>` + article + `</article>
+				<div id="comments"><p>Great post, thanks a lot for writing it, really!</p></div>
+				<footer>Copyright</footer>
+			</body>`,
+			want: "Operators\n" +
+				"Kubernetes operators extend the cluster with custom controllers, which manage complex applications.\n" +
+				"An operator watches its custom resources, compares them with the cluster, and acts to reconcile them.\n",
+		},
+		{
+			name: "content without semantic elements",
+			html: `<body>
+				<div class="menu"><p>Products, pricing, customers, about us and contact.</p></div>
+				<div class="post">` + article + `</div>
+			</body>`,
+			want: "Kubernetes operators extend the cluster with custom controllers, which manage complex applications.\n" +
+				"An operator watches its custom resources, compares them with the cluster, and acts to reconcile them.\n",
+		},
+		{
+			name: "link heavy block",
+			html: `<body>
+				<div><p><a href="/a">A list of links, one, two, three, four, five</a>, <a href="/b">six, seven</a></p></div>
+				<div>` + article + `</div>
+			</body>`,
+			want: "Kubernetes operators extend the cluster with custom controllers, which manage complex applications.\n" +
+				"An operator watches its custom resources, compares them with the cluster, and acts to reconcile them.\n",
+		},
+		{
+			name: "falls back to body",
+			html: `<head><title>T</title></head><body><h1>Short</h1><p>Too short.</p></body>`,
+			want: "Short\nToo short.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readability.Extract(doc); got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "empty", html: ``, want: ""},
+		{name: "blocks", html: `<h1>Title</h1><p>First  paragraph</p><ul><li>one</li><li>two</li></ul>`, want: "Title\nFirst paragraph\none\ntwo\n"},
+		{name: "inline", html: `<p>An <em>inline</em> <a href="#">link</a></p>`, want: "An inline link\n"},
+		{name: "hidden", html: `<head><title>T</title></head><style>p{}</style><p>Shown</p><noscript>Hidden</noscript>`, want: "Shown\n"},
+		{name: "line breaks", html: `<p>one<br>two</p>`, want: "one\ntwo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readability.Text(doc); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package bookmark
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshConcurrency is the number of pages that Refresh fetches at
+// once when no concurrency is given.
+const DefaultRefreshConcurrency = 4
+
+// RefreshOptions configure Refresh.
+type RefreshOptions struct {
+	// Concurrency is the number of pages fetched at once. Zero uses
+	// DefaultRefreshConcurrency.
+	Concurrency int
+	// HostInterval is the minimum time between the start of two fetches
+	// from the same host. Zero does not limit the rate.
+	HostInterval time.Duration
+	// DryRun fetches the metadata without storing it.
+	DryRun bool
+	// Progress is called after every bookmark with the number of bookmarks
+	// that are done. Calls are never concurrent.
+	Progress func(done, total int)
+}
+
+// RefreshResult is the outcome of refreshing the metadata of a bookmark.
+type RefreshResult struct {
+	// Bookmark is the bookmark before the refresh.
+	Bookmark *Bookmark
+	// Updated is the bookmark after the refresh, which is not stored on a
+	// dry run. It is nil when Err is set.
+	Updated *Bookmark
+	Err     error
+}
+
+// TitleChanged reports whether the refresh changed the title.
+func (r RefreshResult) TitleChanged() bool {
+	return r.Err == nil && r.Updated.Title != r.Bookmark.Title
+}
+
+// HasURL reports whether the content of the bookmark is a URL.
+func (b *Bookmark) HasURL() bool {
+	return isURL(b.Content)
+}
+
+// Pending returns the bookmarks whose metadata is pending.
+func (l *Library) Pending() ([]*Bookmark, error) {
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var pending []*Bookmark
+	for _, b := range bookmarks {
+		if b.PendingMetadata {
+			pending = append(pending, b)
+		}
+	}
+	return pending, nil
+}
+
+// Stale returns the bookmarks of URLs whose metadata was fetched longer than
+// age ago or never.
+func (l *Library) Stale(age time.Duration) ([]*Bookmark, error) {
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	before := l.now().Add(-age)
+	var stale []*Bookmark
+	for _, b := range bookmarks {
+		if b.HasURL() && b.Metadata.FetchedAt.Before(before) {
+			stale = append(stale, b)
+		}
+	}
+	return stale, nil
+}
+
+// Refresh fetches the metadata of the bookmarks and stores it. The title is
+// replaced by the title of the page unless it was set by hand, which is the
+// case when it is neither empty, the URL, nor the previous title of the
+// page. The pending flag is cleared on success and kept on failure. The
+// results are in the order of the bookmarks.
+func (l *Library) Refresh(ctx context.Context, bookmarks []*Bookmark, opts RefreshOptions) []RefreshResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultRefreshConcurrency
+	}
+	limiter := &hostLimiter{interval: opts.HostInterval, next: map[string]time.Time{}}
+	results := make([]RefreshResult, len(bookmarks))
+	var mu sync.Mutex
+	var done int
+	forEach(len(bookmarks), concurrency, func(i int) {
+		b := bookmarks[i]
+		updated, err := l.refresh(ctx, b, limiter, opts.DryRun)
+		if err != nil {
+			l.logger.DebugContext(ctx, "could not refresh metadata", slog.String("id", b.ID), slog.Any("error", err))
+		}
+		results[i] = RefreshResult{Bookmark: b, Updated: updated, Err: err}
+		if opts.Progress != nil {
+			mu.Lock()
+			done++
+			opts.Progress(done, len(bookmarks))
+			mu.Unlock()
+		}
+	})
+	return results
+}
+
+// refresh fetches the metadata of a single bookmark and stores it unless
+// dryRun is set.
+func (l *Library) refresh(ctx context.Context, b *Bookmark, limiter *hostLimiter, dryRun bool) (*Bookmark, error) {
+	if !b.HasURL() {
+		return nil, fmt.Errorf("content of %s is not a url", b.ID)
+	}
+	if err := limiter.wait(ctx, b.Content); err != nil {
+		return nil, err
+	}
+	md, err := l.fetcher.Fetch(ctx, b.Content)
+	if err != nil {
+		return nil, err
+	}
+	md.FetchedAt = l.now()
+	pending := false
+	p := &Patch{Metadata: md, PendingMetadata: &pending, UpdatedAt: l.now()}
+	generated := b.Title == "" || b.Title == b.Content || b.Title == b.Metadata.Title
+	if generated && md.Title != "" {
+		p.Title = &md.Title
+	}
+	if dryRun {
+		updated := *b
+		updated.Tags = slices.Clone(b.Tags)
+		p.Apply(&updated)
+		return &updated, nil
+	}
+	return l.Update(b.ID, p)
+}
+
+// hostLimiter spaces the fetches from every host by an interval.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	// next is the earliest time of the next fetch per host.
+	next map[string]time.Time
+}
+
+// wait blocks until the host of rawURL may be fetched again.
+func (h *hostLimiter) wait(ctx context.Context, rawURL string) error {
+	if h.interval <= 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	now := time.Now()
+	at := h.next[u.Host]
+	if at.Before(now) {
+		at = now
+	}
+	h.next[u.Host] = at.Add(h.interval)
+	h.mu.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
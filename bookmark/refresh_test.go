@@ -0,0 +1,276 @@
+package bookmark_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibrary_Add_offline(t *testing.T) {
+	t.Run("offline add should save the url as pending without fetching", func(t *testing.T) {
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithFetcher(bookmark.FetcherFunc(func(context.Context, string) (*bookmark.Metadata, error) {
+				t.Error("offline library should not fetch")
+				return nil, errors.New("offline")
+			})),
+			bookmark.WithOffline(),
+		)
+		b := &bookmark.Bookmark{Content: "https://example.com"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		got, err := lib.Get(b.ID)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		if got.Title != "https://example.com" || !got.PendingMetadata {
+			t.Errorf("Library.Add() stored %+v, want a pending bookmark titled with its url", got)
+		}
+	})
+
+	t.Run("lenient add should save the url as pending when fetching fails", func(t *testing.T) {
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithFetcher(bookmark.FetcherFunc(func(context.Context, string) (*bookmark.Metadata, error) {
+				return nil, errors.New("no network")
+			})),
+			bookmark.WithLenientFetch(),
+		)
+		b := &bookmark.Bookmark{Title: "Example", Content: "https://example.com"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if !b.PendingMetadata {
+			t.Error("Library.Add() should mark the bookmark as pending")
+		}
+	})
+}
+
+func TestLibrary_Refresh(t *testing.T) {
+	newLibrary := func(t *testing.T, fetcher bookmark.Fetcher) *bookmark.Library {
+		t.Helper()
+		lib := bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			bookmark.WithOffline(),
+			bookmark.WithFetcher(fetcher),
+		)
+		for _, b := range []*bookmark.Bookmark{
+			{Content: "https://example.com/a"},
+			{Title: "Own title", Content: "https://example.com/b"},
+			{Content: "https://example.com/fail"},
+			{Title: "Note", Content: "not a url"},
+		} {
+			if err := lib.Add(t.Context(), b); err != nil {
+				t.Fatalf("Library.Add() error = %v", err)
+			}
+		}
+		return lib
+	}
+	fetcher := bookmark.FetcherFunc(func(_ context.Context, url string) (*bookmark.Metadata, error) {
+		if url == "https://example.com/fail" {
+			return nil, errors.New("not found")
+		}
+		return &bookmark.Metadata{Title: "Page " + path.Base(url), SiteName: "Example"}, nil
+	})
+
+	t.Run("refresh should store the metadata of pending bookmarks", func(t *testing.T) {
+		lib := newLibrary(t, fetcher)
+		pending, err := lib.Pending()
+		if err != nil {
+			t.Fatalf("Library.Pending() error = %v", err)
+		}
+		if len(pending) != 3 {
+			t.Fatalf("Library.Pending() returned %d bookmarks, want 3", len(pending))
+		}
+		results := lib.Refresh(t.Context(), pending, bookmark.RefreshOptions{Concurrency: 2})
+		type result struct {
+			Title   string
+			Site    string
+			Pending bool
+			Failed  bool
+		}
+		var got []result
+		for _, r := range results {
+			b := r.Updated
+			if r.Err != nil {
+				b = r.Bookmark
+			}
+			got = append(got, result{
+				Title:   b.Title,
+				Site:    b.Metadata.SiteName,
+				Pending: b.PendingMetadata,
+				Failed:  r.Err != nil,
+			})
+		}
+		want := []result{
+			{Title: "Page a", Site: "Example"},
+			{Title: "Own title", Site: "Example"},
+			{Title: "https://example.com/fail", Pending: true, Failed: true},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.Refresh() mismatch (-want +got):\n%s", diff)
+		}
+		pending, err = lib.Pending()
+		if err != nil {
+			t.Fatalf("Library.Pending() error = %v", err)
+		}
+		if len(pending) != 1 || pending[0].Content != "https://example.com/fail" {
+			t.Errorf("Library.Pending() = %+v, want only the failed bookmark", pending)
+		}
+	})
+
+	t.Run("refresh should fetch at most concurrency pages at once", func(t *testing.T) {
+		var mu sync.Mutex
+		var running, peak int
+		lib := newLibrary(t, bookmark.FetcherFunc(func(_ context.Context, _ string) (*bookmark.Metadata, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return &bookmark.Metadata{Title: "Page"}, nil
+		}))
+		bookmarks, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		var urls []*bookmark.Bookmark
+		for range 4 {
+			urls = append(urls, bookmarks[:3]...)
+		}
+		lib.Refresh(t.Context(), urls, bookmark.RefreshOptions{Concurrency: 2})
+		if peak != 2 {
+			t.Errorf("peak concurrency = %d, want 2", peak)
+		}
+	})
+
+	t.Run("refresh should replace titles of pages but not titles set by hand", func(t *testing.T) {
+		version := "v1"
+		lib := newLibrary(t, bookmark.FetcherFunc(func(_ context.Context, url string) (*bookmark.Metadata, error) {
+			return &bookmark.Metadata{Title: path.Base(url) + " " + version}, nil
+		}))
+		bookmarks, err := lib.List()
+		if err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		lib.Refresh(t.Context(), bookmarks[:2], bookmark.RefreshOptions{})
+		version = "v2"
+		if bookmarks, err = lib.List(); err != nil {
+			t.Fatalf("Library.List() error = %v", err)
+		}
+		var got []string
+		for _, r := range lib.Refresh(t.Context(), bookmarks[:2], bookmark.RefreshOptions{}) {
+			got = append(got, r.Updated.Title)
+		}
+		if diff := cmp.Diff([]string{"a v2", "Own title"}, got); diff != "" {
+			t.Errorf("Library.Refresh() titles mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("dry run should not store the metadata", func(t *testing.T) {
+		lib := newLibrary(t, fetcher)
+		pending, err := lib.Pending()
+		if err != nil {
+			t.Fatalf("Library.Pending() error = %v", err)
+		}
+		results := lib.Refresh(t.Context(), pending, bookmark.RefreshOptions{DryRun: true})
+		if !results[0].TitleChanged() || results[0].Updated.Title != "Page a" {
+			t.Errorf("dry run result = %+v, want title change to %q", results[0].Updated, "Page a")
+		}
+		stored, err := lib.Get(pending[0].ID)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		if diff := cmp.Diff(pending[0], stored); diff != "" {
+			t.Errorf("dry run changed the stored bookmark (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("refresh should report progress", func(t *testing.T) {
+		lib := newLibrary(t, fetcher)
+		pending, err := lib.Pending()
+		if err != nil {
+			t.Fatalf("Library.Pending() error = %v", err)
+		}
+		var got []int
+		lib.Refresh(t.Context(), pending, bookmark.RefreshOptions{
+			Progress: func(done, total int) {
+				if total != len(pending) {
+					t.Errorf("progress total = %d, want %d", total, len(pending))
+				}
+				got = append(got, done)
+			},
+		})
+		if diff := cmp.Diff([]int{1, 2, 3}, got); diff != "" {
+			t.Errorf("progress mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("refresh should space fetches from the same host", func(t *testing.T) {
+		var mu sync.Mutex
+		var starts []time.Time
+		lib := newLibrary(t, bookmark.FetcherFunc(func(_ context.Context, _ string) (*bookmark.Metadata, error) {
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+			return &bookmark.Metadata{Title: "Page"}, nil
+		}))
+		pending, err := lib.Pending()
+		if err != nil {
+			t.Fatalf("Library.Pending() error = %v", err)
+		}
+		const interval = 20 * time.Millisecond
+		lib.Refresh(t.Context(), pending, bookmark.RefreshOptions{Concurrency: 3, HostInterval: interval})
+		slices.SortFunc(starts, time.Time.Compare)
+		for i := 1; i < len(starts); i++ {
+			// timers may fire slightly early on some platforms
+			if d := starts[i].Sub(starts[i-1]); d < interval-time.Millisecond {
+				t.Errorf("fetches %d and %d were %v apart, want at least %v", i-1, i, d, interval)
+			}
+		}
+	})
+}
+
+func TestLibrary_Stale(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	// the store is filled directly, so no pages are fetched
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	for _, b := range []*bookmark.Bookmark{
+		{Title: "Fresh", Content: "https://example.com/fresh", Metadata: bookmark.Metadata{FetchedAt: now.AddDate(0, 0, -1)}},
+		{Title: "Old", Content: "https://example.com/old", Metadata: bookmark.Metadata{FetchedAt: now.AddDate(0, -2, 0)}},
+		{Title: "Never", Content: "https://example.com/never", PendingMetadata: true},
+		{Title: "Note", Content: "not a url"},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store, bookmark.WithClock(func() time.Time { return now }))
+	stale, err := lib.Stale(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Library.Stale() error = %v", err)
+	}
+	var got []string
+	for _, b := range stale {
+		got = append(got, b.Title)
+	}
+	if diff := cmp.Diff([]string{"Old", "Never"}, got); diff != "" {
+		t.Errorf("Library.Stale() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,116 @@
+package bookmark
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+)
+
+var (
+	// ErrSearchNotFound is returned when a saved search is not found.
+	ErrSearchNotFound = errors.New("saved search not found")
+	// ErrNoSearchStore is returned when saving searches in a store that
+	// cannot keep them.
+	ErrNoSearchStore = errors.New("store does not support saved searches")
+)
+
+// SavedSearch is a search query that is saved under a name. It is evaluated
+// every time it is run, so it acts like a folder of the bookmarks that match.
+type SavedSearch struct {
+	Name  string
+	Query string
+	// Mode is how the words of the query match, see SearchOptions.
+	Mode      query.Mode
+	CreatedAt time.Time
+}
+
+// SearchStore is implemented by stores that keep saved searches.
+type SearchStore interface {
+	// SaveSearch saves a search, replacing the search with the same name.
+	SaveSearch(s *SavedSearch) error
+	// Searches returns the saved searches, sorted by name.
+	Searches() ([]*SavedSearch, error)
+	// DeleteSearch deletes the search with the given name. It returns
+	// ErrSearchNotFound when there is none.
+	DeleteSearch(name string) error
+}
+
+// searchStore returns the store as a SearchStore.
+func (l *Library) searchStore() (SearchStore, error) {
+	s, ok := l.store.(SearchStore)
+	if !ok {
+		return nil, ErrNoSearchStore
+	}
+	return s, nil
+}
+
+// SaveSearch saves a query under a name, replacing the search with the same
+// name. The query must be valid, errors in it are of type *query.Error.
+func (l *Library) SaveSearch(name, q string, opts SearchOptions) (*SavedSearch, error) {
+	store, err := l.searchStore()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name of saved search is empty")
+	}
+	if strings.TrimSpace(q) == "" {
+		return nil, errors.New("query of saved search is empty")
+	}
+	s := &SavedSearch{Name: name, Query: q, Mode: opts.Mode, CreatedAt: l.now()}
+	if _, err = query.Parse(q, cmp.Or(s.Mode, query.ModeSubstring), l.now()); err != nil {
+		return nil, err
+	}
+	if err = store.SaveSearch(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SavedSearches returns the saved searches, sorted by name. Stores that
+// cannot keep saved searches have none.
+func (l *Library) SavedSearches() ([]*SavedSearch, error) {
+	store, ok := l.store.(SearchStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.Searches()
+}
+
+// SavedSearch returns the saved search with the given name.
+func (l *Library) SavedSearch(name string) (*SavedSearch, error) {
+	searches, err := l.SavedSearches()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range searches {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSearchNotFound, name)
+}
+
+// RunSearch runs the saved search with the given name against the current
+// bookmarks.
+func (l *Library) RunSearch(name string) ([]SearchResult, error) {
+	s, err := l.SavedSearch(name)
+	if err != nil {
+		return nil, err
+	}
+	return l.Search(s.Query, SearchOptions{Mode: s.Mode})
+}
+
+// DeleteSearch deletes the saved search with the given name.
+func (l *Library) DeleteSearch(name string) error {
+	store, err := l.searchStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteSearch(name)
+}
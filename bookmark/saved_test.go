@@ -0,0 +1,107 @@
+package bookmark_test
+
+import (
+	"errors"
+	"log/slog"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibrary_SaveSearch(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	newLibrary := func(t *testing.T) *bookmark.Library {
+		t.Helper()
+		store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+		for _, b := range []*bookmark.Bookmark{
+			{ID: "1", Title: "Go blog", Content: "https://go.dev/blog", Tags: []string{"go"}},
+			{ID: "2", Title: "Rust blog", Content: "https://blog.rust-lang.org"},
+		} {
+			if err := store.Add(b); err != nil {
+				t.Fatalf("Store.Add() error = %v", err)
+			}
+		}
+		return bookmark.NewLibrary(slog.New(slog.DiscardHandler), store, bookmark.WithClock(func() time.Time { return now }))
+	}
+
+	t.Run("saved searches should be listed by name", func(t *testing.T) {
+		lib := newLibrary(t)
+		for _, s := range []struct{ name, query string }{{"untagged", "-tag:*"}, {" go ", "tag:go"}} {
+			if _, err := lib.SaveSearch(s.name, s.query, bookmark.SearchOptions{}); err != nil {
+				t.Fatalf("Library.SaveSearch() error = %v", err)
+			}
+		}
+		got, err := lib.SavedSearches()
+		if err != nil {
+			t.Fatalf("Library.SavedSearches() error = %v", err)
+		}
+		want := []*bookmark.SavedSearch{
+			{Name: "go", Query: "tag:go", CreatedAt: now},
+			{Name: "untagged", Query: "-tag:*", CreatedAt: now},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Library.SavedSearches() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("run search should evaluate the query against the current bookmarks", func(t *testing.T) {
+		lib := newLibrary(t)
+		if _, err := lib.SaveSearch("blogs", "blg", bookmark.SearchOptions{Mode: query.ModeFuzzy}); err != nil {
+			t.Fatalf("Library.SaveSearch() error = %v", err)
+		}
+		if err := lib.Delete("2"); err != nil {
+			t.Fatalf("Library.Delete() error = %v", err)
+		}
+		results, err := lib.RunSearch("blogs")
+		if err != nil {
+			t.Fatalf("Library.RunSearch() error = %v", err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Bookmark.ID)
+		}
+		if diff := cmp.Diff([]string{"1"}, ids); diff != "" {
+			t.Errorf("Library.RunSearch() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("save search should replace the search with the same name", func(t *testing.T) {
+		lib := newLibrary(t)
+		for _, q := range []string{"go", "rust"} {
+			if _, err := lib.SaveSearch("lang", q, bookmark.SearchOptions{}); err != nil {
+				t.Fatalf("Library.SaveSearch() error = %v", err)
+			}
+		}
+		got, err := lib.SavedSearch("lang")
+		if err != nil {
+			t.Fatalf("Library.SavedSearch() error = %v", err)
+		}
+		if got.Query != "rust" {
+			t.Errorf("Library.SavedSearch() query = %q, want %q", got.Query, "rust")
+		}
+	})
+
+	t.Run("save search should reject invalid queries", func(t *testing.T) {
+		lib := newLibrary(t)
+		_, err := lib.SaveSearch("broken", "(go", bookmark.SearchOptions{})
+		var qErr *query.Error
+		if !errors.As(err, &qErr) {
+			t.Errorf("Library.SaveSearch() error = %v, want a query error", err)
+		}
+	})
+
+	t.Run("unknown searches should return ErrSearchNotFound", func(t *testing.T) {
+		lib := newLibrary(t)
+		if _, err := lib.RunSearch("missing"); !errors.Is(err, bookmark.ErrSearchNotFound) {
+			t.Errorf("Library.RunSearch() error = %v, want %v", err, bookmark.ErrSearchNotFound)
+		}
+		if err := lib.DeleteSearch("missing"); !errors.Is(err, bookmark.ErrSearchNotFound) {
+			t.Errorf("Library.DeleteSearch() error = %v, want %v", err, bookmark.ErrSearchNotFound)
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package bookmark
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+)
+
+// Index is a full-text index of bookmarks. The library keeps it in sync
+// with the store.
+type Index interface {
+	// Put indexes a bookmark, replacing the bookmark with the same ID.
+	Put(b *Bookmark) error
+	Delete(id string) error
+	// Rebuild replaces the content of the index by the bookmarks.
+	Rebuild(bookmarks []*Bookmark) error
+	// Search returns the bookmarks that match the query, most relevant
+	// first.
+	Search(query string) ([]Hit, error)
+	// Snippets returns the parts of a bookmark that match the query.
+	Snippets(b *Bookmark, query string) []Snippet
+	// Version returns the version of the store that the index is in sync
+	// with, see VersionedStore. It is empty when that is unknown.
+	Version() string
+	// SetVersion sets the version of the store that the index is in sync
+	// with.
+	SetVersion(v string)
+}
+
+// VersionedStore is implemented by stores that tell when their bookmarks
+// changed, so an index that other processes did not keep in sync can be
+// rebuilt.
+type VersionedStore interface {
+	// Version returns a value that changes whenever bookmarks are added,
+	// updated or deleted.
+	Version() (string, error)
+}
+
+// Hit is a bookmark found in an index.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Snippet is a part of a field of a bookmark that matches a query.
+type Snippet struct {
+	// Field is the name of the field, like title or body.
+	Field string
+	Text  string
+	// Highlights are the parts of Text that match the query.
+	Highlights []Range
+}
+
+// Range is a part of a text from Start up to End, in bytes.
+type Range struct {
+	Start, End int
+}
+
+// Querier is implemented by stores that find the bookmarks that match a
+// query expression themselves, in the order of List.
+type Querier interface {
+	Query(e query.Expr) ([]*Bookmark, error)
+}
+
+// SearchOptions configure a search.
+type SearchOptions struct {
+	// Mode is how words and phrases match, query.ModeSubstring when empty.
+	Mode query.Mode
+}
+
+// SearchResult is a bookmark that matches a search query.
+type SearchResult struct {
+	Bookmark *Bookmark
+	// Score is the relevance of the bookmark, higher is more relevant. It is
+	// the score of the index when it is used, and otherwise how well the
+	// words of the query match.
+	Score float64
+	// Matches are the parts of the fields that the words of the query
+	// match, to highlight them.
+	Matches  []Match
+	Snippets []Snippet
+}
+
+// WithIndex sets the full-text index used by Search.
+func WithIndex(i Index) Option {
+	return func(l *Library) {
+		l.index = i
+	}
+}
+
+// Reindex rebuilds the index from all bookmarks in the store and returns
+// the number of bookmarks that were indexed.
+func (l *Library) Reindex() (int, error) {
+	if l.index == nil {
+		return 0, nil
+	}
+	l.indexMu.Lock()
+	defer l.indexMu.Unlock()
+	// a change after reading the version outdates the index again
+	version := l.storeVersion()
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return 0, err
+	}
+	if err = l.index.Rebuild(bookmarks); err != nil {
+		return 0, err
+	}
+	l.index.SetVersion(version)
+	return len(bookmarks), nil
+}
+
+// IndexOutdated reports whether the index misses changes of the store,
+// because it is new or other processes changed the store without keeping
+// it in sync. Reindex brings it up to date.
+func (l *Library) IndexOutdated() bool {
+	if l.index == nil {
+		return false
+	}
+	v := l.storeVersion()
+	return v == "" || v != l.index.Version()
+}
+
+// storeVersion returns the version of the store, or the empty string when
+// the store has none.
+func (l *Library) storeVersion() string {
+	s, ok := l.store.(VersionedStore)
+	if !ok {
+		return ""
+	}
+	v, err := s.Version()
+	if err != nil {
+		l.logger.Warn("could not get version of store", slog.Any("error", err))
+		return ""
+	}
+	return v
+}
+
+// change runs a change of the bookmarks in the store. The index stays in
+// sync with the store when nothing else changed the store since the index
+// was last in sync, otherwise it is outdated until it is rebuilt. Changes
+// are serialized, so they can be told apart from changes of other
+// processes.
+func (l *Library) change(fn func() error) error {
+	if l.index == nil {
+		return fn()
+	}
+	l.indexMu.Lock()
+	defer l.indexMu.Unlock()
+	before := l.storeVersion()
+	if err := fn(); err != nil {
+		return err
+	}
+	if before == "" || before != l.index.Version() {
+		l.index.SetVersion("")
+		return nil
+	}
+	l.index.SetVersion(l.storeVersion())
+	return nil
+}
+
+// indexPut indexes a bookmark that was stored. The store is leading, so
+// failing to index is only logged. Reindex repairs the index.
+func (l *Library) indexPut(b *Bookmark) {
+	if l.index == nil {
+		return
+	}
+	if err := l.index.Put(b); err != nil {
+		l.logger.Warn("could not index bookmark", slog.String("id", b.ID), slog.Any("error", err))
+	}
+}
+
+// Search searches for bookmarks in the library with a query, see package
+// query for its syntax. Query errors are of type *query.Error. Stores that
+// implement Querier evaluate the query themselves, other stores are
+// searched in memory.
+//
+// Results are ranked by how well the words of the query that are not
+// negated match, matches in the title counting most. With an index,
+// substring searches rank by the score of the index instead and words also
+// match the bookmarks that the index finds for them, so they match
+// regardless of word endings. Results then get highlighted snippets too.
+func (l *Library) Search(q string, opts SearchOptions) ([]SearchResult, error) {
+	mode := cmp.Or(opts.Mode, query.ModeSubstring)
+	e, err := query.Parse(q, mode, l.now())
+	if err != nil {
+		return nil, err
+	}
+	terms := query.Terms(e)
+	var text []string
+	for _, t := range terms {
+		if t.Field == query.FieldText {
+			text = append(text, t.Value)
+		}
+	}
+	index := l.index != nil && mode == query.ModeSubstring
+	scores := map[string]float64{}
+	if index {
+		if e, err = l.resolveWords(e, scores); err != nil {
+			return nil, err
+		}
+	}
+	bookmarks, err := l.query(e)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(bookmarks))
+	quality := make(map[string]float64, len(bookmarks))
+	for _, b := range bookmarks {
+		r := SearchResult{Bookmark: b}
+		quality[b.ID], r.Matches = b.rank(terms)
+		r.Score = quality[b.ID]
+		if index {
+			r.Score = scores[b.ID]
+			if len(text) > 0 {
+				r.Snippets = l.index.Snippets(b, strings.Join(text, " "))
+			}
+		}
+		results = append(results, r)
+	}
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(quality[b.Bookmark.ID], quality[a.Bookmark.ID]))
+	})
+	return results, nil
+}
+
+// resolveWords lets the words of a query also match the bookmarks that the
+// index finds for them. The scores of the words that are not negated are
+// added to scores by bookmark ID.
+func (l *Library) resolveWords(e query.Expr, scores map[string]float64) (query.Expr, error) {
+	positive := query.Terms(e)
+	var err error
+	e = query.Rewrite(e, func(e query.Expr) query.Expr {
+		t, ok := e.(*query.Term)
+		if !ok || t.Field != query.FieldText || t.Phrase || err != nil {
+			return e
+		}
+		var hits []Hit
+		if hits, err = l.index.Search(t.Value); err != nil {
+			return e
+		}
+		ids := &query.IDs{}
+		for _, h := range hits {
+			ids.IDs = append(ids.IDs, h.ID)
+			if slices.Contains(positive, t) {
+				scores[h.ID] += h.Score
+			}
+		}
+		return &query.Or{Left: t, Right: ids}
+	})
+	return e, err
+}
+
+// query returns the bookmarks that match the expression.
+func (l *Library) query(e query.Expr) ([]*Bookmark, error) {
+	if q, ok := l.store.(Querier); ok {
+		return q.Query(e)
+	}
+	bookmarks, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(bookmarks, func(b *Bookmark) bool {
+		return !b.Match(e)
+	}), nil
+}
+
+// Bookmarks returns the bookmarks of the results.
+func Bookmarks(results []SearchResult) []*Bookmark {
+	bookmarks := make([]*Bookmark, 0, len(results))
+	for _, r := range results {
+		bookmarks = append(bookmarks, r.Bookmark)
+	}
+	return bookmarks
+}
@@ -0,0 +1,402 @@
+// Package search is a full-text index of bookmarks. Bookmarks are ranked
+// with BM25F over their title, description, tags, URL and page text.
+package search
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+var _ bookmark.Index = &Index{}
+
+// version is the version of the index file. Index files of other versions
+// are ignored, so they are rebuilt.
+const version = 2
+
+// field is a part of a bookmark that is indexed.
+type field int
+
+// Indexed fields.
+const (
+	fieldTitle field = iota
+	fieldDescription
+	fieldTags
+	fieldURL
+	fieldBody
+	numFields
+)
+
+//nolint:gochecknoglobals // static tables of the fields
+var (
+	// fieldNames are the names of the fields in snippets.
+	fieldNames = [numFields]string{"title", "description", "tags", "url", "body"}
+	// weights boost matches in short and descriptive fields.
+	weights = [numFields]float64{3, 1.5, 2, 1, 1}
+)
+
+// BM25 parameters. k1 limits how much repeating a term adds, lengthNorm is
+// how much long fields are penalized.
+const (
+	k1         = 1.2
+	lengthNorm = 0.75
+)
+
+// frequencies are the number of times a term occurs in every field.
+type frequencies [numFields]int
+
+// document is an indexed bookmark.
+type document struct {
+	// Terms are the distinct terms of the bookmark.
+	Terms []string
+	// Lengths are the number of terms in every field.
+	Lengths [numFields]int
+}
+
+// data is what the index file holds.
+type data struct {
+	Version int
+	// StoreVersion is the version of the store that the index is in sync
+	// with.
+	StoreVersion string
+	Documents    map[string]*document
+	// Postings are the frequencies of every term by bookmark ID.
+	Postings map[string]map[string]frequencies
+}
+
+// Index is an inverted index of bookmarks. It is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	path string
+	data data
+	// totals are the summed lengths of every field.
+	totals [numFields]int
+	dirty  bool
+}
+
+// New creates an empty index that is kept in memory.
+func New() *Index {
+	return &Index{data: emptyData()}
+}
+
+// Open opens the index in the file at path. The index is empty when the
+// file does not exist, cannot be decoded or was written by another version,
+// so it needs to be rebuilt. Changes are written to the file by Close,
+// together with the version of the store that the index is in sync with,
+// so an index that misses changes of other processes is rebuilt.
+func Open(path string) (*Index, error) {
+	idx := &Index{path: path, data: emptyData()}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open index: %w", err)
+	}
+	defer f.Close()
+	// gob leaves out empty maps, so decode into empty ones
+	d := emptyData()
+	d.Version = 0
+	if err = gob.NewDecoder(f).Decode(&d); err != nil || d.Version != version {
+		return idx, nil
+	}
+	idx.data = d
+	for _, doc := range d.Documents {
+		for f, n := range doc.Lengths {
+			idx.totals[f] += n
+		}
+	}
+	return idx, nil
+}
+
+// emptyData returns the data of an empty index.
+func emptyData() data {
+	return data{
+		Version:   version,
+		Documents: map[string]*document{},
+		Postings:  map[string]map[string]frequencies{},
+	}
+}
+
+// Len returns the number of indexed bookmarks.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.data.Documents)
+}
+
+// Put indexes a bookmark, replacing the bookmark with the same ID.
+func (idx *Index) Put(b *bookmark.Bookmark) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(b.ID)
+	idx.add(b)
+	idx.dirty = true
+	return nil
+}
+
+// Delete removes the bookmark with the given ID from the index.
+func (idx *Index) Delete(id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+	idx.dirty = true
+	return nil
+}
+
+// Version implements bookmark.Index.
+func (idx *Index) Version() string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.data.StoreVersion
+}
+
+// SetVersion implements bookmark.Index.
+func (idx *Index) SetVersion(v string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.data.StoreVersion != v {
+		idx.data.StoreVersion = v
+		idx.dirty = true
+	}
+}
+
+// Rebuild replaces the content of the index by the bookmarks.
+func (idx *Index) Rebuild(bookmarks []*bookmark.Bookmark) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.data = emptyData()
+	idx.totals = [numFields]int{}
+	for _, b := range bookmarks {
+		idx.add(b)
+	}
+	idx.dirty = true
+	return nil
+}
+
+// Close writes the changes of an index that was opened from a file.
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty || idx.path == "" {
+		return nil
+	}
+	if err := idx.save(); err != nil {
+		return fmt.Errorf("could not write index: %w", err)
+	}
+	idx.dirty = false
+	return nil
+}
+
+// save writes the index to a temporary file first, so the index file is
+// never partial.
+func (idx *Index) save() error {
+	dir := filepath.Dir(idx.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(idx.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err = gob.NewEncoder(f).Encode(idx.data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), idx.path)
+}
+
+// add indexes a bookmark that is not in the index.
+func (idx *Index) add(b *bookmark.Bookmark) {
+	doc := &document{}
+	freqs := map[string]frequencies{}
+	for f, text := range fieldTexts(b) {
+		tokens := Tokenize(text)
+		doc.Lengths[f] = len(tokens)
+		idx.totals[f] += len(tokens)
+		for _, t := range tokens {
+			fr := freqs[t.Term]
+			fr[f]++
+			freqs[t.Term] = fr
+		}
+	}
+	for term, fr := range freqs {
+		doc.Terms = append(doc.Terms, term)
+		postings, ok := idx.data.Postings[term]
+		if !ok {
+			postings = map[string]frequencies{}
+			idx.data.Postings[term] = postings
+		}
+		postings[b.ID] = fr
+	}
+	slices.Sort(doc.Terms)
+	idx.data.Documents[b.ID] = doc
+}
+
+// remove removes a bookmark from the index if it is indexed.
+func (idx *Index) remove(id string) {
+	doc, ok := idx.data.Documents[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.Terms {
+		postings := idx.data.Postings[term]
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(idx.data.Postings, term)
+		}
+	}
+	for f, n := range doc.Lengths {
+		idx.totals[f] -= n
+	}
+	delete(idx.data.Documents, id)
+}
+
+// fieldTexts returns the text of every field of a bookmark. The content of
+// bookmarks that are not URLs is their body.
+func fieldTexts(b *bookmark.Bookmark) [numFields]string {
+	var texts [numFields]string
+	texts[fieldTitle] = b.Title
+	texts[fieldDescription] = b.Metadata.Description
+	texts[fieldTags] = strings.Join(b.Tags, " ")
+	if b.HasURL() {
+		texts[fieldURL] = b.Content
+		texts[fieldBody] = b.Metadata.Text
+	} else {
+		texts[fieldBody] = b.Content
+	}
+	return texts
+}
+
+// Search returns the bookmarks that contain every term of the query, most
+// relevant first. A term that ends with * matches every term that starts
+// with it.
+func (idx *Index) Search(query string) ([]bookmark.Hit, error) {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// every query term expands to the indexed terms it matches
+	expanded := make([][]string, len(terms))
+	for i, t := range terms {
+		expanded[i] = idx.expand(t)
+		if len(expanded[i]) == 0 {
+			return nil, nil
+		}
+	}
+	var scores map[string]float64
+	for i := range terms {
+		matches := map[string]float64{}
+		for _, term := range expanded[i] {
+			for id, fr := range idx.data.Postings[term] {
+				matches[id] += idx.score(term, idx.data.Documents[id], fr)
+			}
+		}
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id := range scores {
+			if s, ok := matches[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]bookmark.Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, bookmark.Hit{ID: id, Score: s})
+	}
+	slices.SortFunc(hits, func(a, b bookmark.Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return hits, nil
+}
+
+// expand returns the indexed terms that a query term matches.
+func (idx *Index) expand(t queryTerm) []string {
+	if !t.prefix {
+		if _, ok := idx.data.Postings[t.term]; ok {
+			return []string{t.term}
+		}
+		return nil
+	}
+	var terms []string
+	for term := range idx.data.Postings {
+		if strings.HasPrefix(term, t.term) {
+			terms = append(terms, term)
+		}
+	}
+	slices.Sort(terms)
+	return terms
+}
+
+// score returns the BM25F score of a term for a bookmark. The frequencies
+// of the fields are normalized by their length and weighted before they
+// saturate.
+func (idx *Index) score(term string, doc *document, fr frequencies) float64 {
+	n := float64(len(idx.data.Documents))
+	var tf float64
+	for f := range numFields {
+		if fr[f] == 0 {
+			continue
+		}
+		avg := float64(idx.totals[f]) / n
+		if avg == 0 {
+			avg = 1
+		}
+		tf += weights[f] * float64(fr[f]) / (1 - lengthNorm + lengthNorm*float64(doc.Lengths[f])/avg)
+	}
+	df := float64(len(idx.data.Postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	return idf * tf * (k1 + 1) / (tf + k1)
+}
+
+// queryTerm is a term of a query.
+type queryTerm struct {
+	term string
+	// prefix matches every term that starts with term.
+	prefix bool
+}
+
+// parseQuery returns the terms of a query. Prefix terms are folded but not
+// stemmed, as the stem of a partial word is meaningless.
+func parseQuery(query string) []queryTerm {
+	var terms []queryTerm
+	for _, word := range strings.Fields(query) {
+		prefix := strings.HasSuffix(word, "*")
+		tokens := Tokenize(word)
+		for i, t := range tokens {
+			qt := queryTerm{term: t.Term}
+			if prefix && i == len(tokens)-1 {
+				qt = queryTerm{term: fold(word[t.Start:t.End]), prefix: true}
+			}
+			terms = append(terms, qt)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,213 @@
+package search_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/search"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"generalization": "gener",
+		"operator":       "oper",
+		"operators":      "oper",
+		"operating":      "oper",
+		"controller":     "control",
+		"go":             "go",
+		"k8s":            "k8s",
+	}
+	for word, want := range tests {
+		if got := search.Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := search.Tokenize("Café, ÜBER-Operators!\n go.dev")
+	want := []search.Token{
+		{Term: "cafe", Start: 0, End: 5},
+		{Term: "uber", Start: 7, End: 12},
+		{Term: "oper", Start: 13, End: 22},
+		{Term: "go", Start: 25, End: 27},
+		{Term: "dev", Start: 28, End: 31},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokenize() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// bookmarks are indexed by the tests.
+func bookmarks() []*bookmark.Bookmark {
+	return []*bookmark.Bookmark{
+		{
+			ID: "1", Title: "Kubernetes operators", Content: "https://example.com/operators",
+			Metadata: bookmark.Metadata{Text: "An operator reconciles custom resources."},
+		},
+		{
+			ID: "2", Title: "Cluster notes", Content: "https://example.com/notes",
+			Metadata: bookmark.Metadata{Text: "Running Kubernetes at scale. We wrote an operator for backups."},
+		},
+		{
+			ID: "3", Title: "Go blog", Content: "https://go.dev/blog", Tags: []string{"go"},
+			Metadata: bookmark.Metadata{Description: "The Go programming language blog."},
+		},
+		{ID: "4", Title: "Shopping list", Content: "Café au lait, croissants"},
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := search.New()
+	if err := idx.Rebuild(bookmarks()); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		query   string
+		wantIDs []string
+	}{
+		{name: "title ranks above body", query: "kubernetes operator", wantIDs: []string{"1", "2"}},
+		{name: "every term must match", query: "kubernetes backups", wantIDs: []string{"2"}},
+		{name: "stemmed", query: "operating", wantIDs: []string{"1", "2"}},
+		{name: "folded", query: "CAFE", wantIDs: []string{"4"}},
+		{name: "prefix", query: "kube*", wantIDs: []string{"1", "2"}},
+		{name: "tags and url", query: "go dev", wantIDs: []string{"3"}},
+		{name: "no match", query: "rust", wantIDs: nil},
+		{name: "empty", query: "  ", wantIDs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := idx.Search(tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var ids []string
+			for i, h := range hits {
+				ids = append(ids, h.ID)
+				if h.Score <= 0 || (i > 0 && h.Score > hits[i-1].Score) {
+					t.Errorf("Search() hit %s has score %v after %v", h.ID, h.Score, hits[max(i-1, 0)].Score)
+				}
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_Put(t *testing.T) {
+	idx := search.New()
+	bs := bookmarks()
+	if err := idx.Rebuild(bs); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	t.Run("put should replace the bookmark", func(t *testing.T) {
+		b := *bs[2]
+		b.Title = "Rust blog"
+		b.Metadata.Description = ""
+		if err := idx.Put(&b); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if hits, _ := idx.Search("programming"); len(hits) != 0 {
+			t.Errorf("Search() = %v, want no hits for the old description", hits)
+		}
+		if hits, _ := idx.Search("rust"); len(hits) != 1 || hits[0].ID != "3" {
+			t.Errorf("Search() = %v, want bookmark 3", hits)
+		}
+		if idx.Len() != len(bs) {
+			t.Errorf("Len() = %d, want %d", idx.Len(), len(bs))
+		}
+	})
+
+	t.Run("delete should remove the bookmark", func(t *testing.T) {
+		if err := idx.Delete("1"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		hits, _ := idx.Search("kubernetes")
+		if len(hits) != 1 || hits[0].ID != "2" {
+			t.Errorf("Search() = %v, want bookmark 2", hits)
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.index")
+	idx, err := search.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if idx.Len() != 0 {
+		t.Errorf("Len() = %d, want an empty index", idx.Len())
+	}
+	if err = idx.Rebuild(bookmarks()); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	idx.SetVersion("42")
+	if err = idx.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := search.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	want, _ := idx.Search("kubernetes operator")
+	got, err := reopened.Search("kubernetes operator")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Search() after reopening mismatch (-want +got):\n%s", diff)
+	}
+	if v := reopened.Version(); v != "42" {
+		t.Errorf("Version() after reopening = %q, want %q", v, "42")
+	}
+}
+
+func TestIndex_Snippets(t *testing.T) {
+	idx := search.New()
+	b := &bookmark.Bookmark{
+		Title:   "Kubernetes operators",
+		Content: "https://example.com/operators",
+		Metadata: bookmark.Metadata{Text: "One two three four five six seven eight nine ten eleven twelve thirteen\n" +
+			"fourteen fifteen sixteen seventeen eighteen nineteen twenty. An operator reconciles custom resources " +
+			"of the cluster. The end of the page has more words that do not matter at all for the query."},
+	}
+	got := idx.Snippets(b, "operator")
+	want := []bookmark.Snippet{
+		{Field: "title", Text: "Kubernetes operators", Highlights: []bookmark.Range{{Start: 11, End: 20}}},
+		{
+			Field: "body",
+			Text: "…eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty. " +
+				"An operator reconciles custom resources of the cluster. The end of the page has…",
+			Highlights: []bookmark.Range{{Start: 90, End: 98}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Snippets() mismatch (-want +got):\n%s", diff)
+	}
+	for _, s := range got {
+		for _, h := range s.Highlights {
+			if m := s.Text[h.Start:h.End]; m != "operators" && m != "operator" {
+				t.Errorf("highlight %v of %s is %q", h, s.Field, m)
+			}
+		}
+	}
+}
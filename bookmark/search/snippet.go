@@ -0,0 +1,117 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+// snippetWords is the number of words of a snippet.
+const snippetWords = 24
+
+// ellipsis marks text that is cut from a snippet.
+const ellipsis = "…"
+
+// lineBreaks replaces line breaks and tabs by spaces of the same length, so
+// offsets in the text stay valid.
+//
+//nolint:gochecknoglobals // a replacer is safe for concurrent use
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ")
+
+// Snippets returns the title, description and body of a bookmark with the
+// terms of the query highlighted. Long texts are cut to the part with the
+// most matches. Fields without matches are left out.
+func (idx *Index) Snippets(b *bookmark.Bookmark, query string) []bookmark.Snippet {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	texts := fieldTexts(b)
+	var snippets []bookmark.Snippet
+	for _, f := range []field{fieldTitle, fieldDescription, fieldBody} {
+		if s, ok := snippet(texts[f], terms); ok {
+			s.Field = fieldNames[f]
+			snippets = append(snippets, s)
+		}
+	}
+	return snippets
+}
+
+// matches reports whether an indexed term matches the query term.
+func (t queryTerm) matches(term string) bool {
+	if t.prefix {
+		return strings.HasPrefix(term, t.term)
+	}
+	return term == t.term
+}
+
+// snippet returns the words of the text with the most matches of the
+// terms, or false when nothing matches.
+func snippet(text string, terms []queryTerm) (bookmark.Snippet, bool) {
+	tokens := Tokenize(text)
+	matched := make([]bool, len(tokens))
+	found := false
+	for i, t := range tokens {
+		matched[i] = slices.ContainsFunc(terms, func(q queryTerm) bool { return q.matches(t.Term) })
+		found = found || matched[i]
+	}
+	if !found {
+		return bookmark.Snippet{}, false
+	}
+
+	// slide a window over the words and keep the first with the most
+	// matches, then center the matches in it
+	n := min(snippetWords, len(tokens))
+	var count int
+	for _, m := range matched[:n] {
+		if m {
+			count++
+		}
+	}
+	start, most := 0, count
+	for i := n; i < len(tokens); i++ {
+		if matched[i] {
+			count++
+		}
+		if matched[i-n] {
+			count--
+		}
+		if count > most {
+			start, most = i-n+1, count
+		}
+	}
+	first, last := start, start+n-1
+	for !matched[first] {
+		first++
+	}
+	for !matched[last] {
+		last--
+	}
+	start = min(max(first-(n-(last-first+1))/2, 0), len(tokens)-n)
+	end := start + n
+
+	var s bookmark.Snippet
+	from, to := 0, len(text)
+	if start > 0 {
+		from = tokens[start].Start
+		s.Text = ellipsis
+	}
+	if end < len(tokens) {
+		to = tokens[end-1].End
+	}
+	part := lineBreaks.Replace(text[from:to])
+	trimmed := strings.TrimLeftFunc(part, unicode.IsSpace)
+	offset := len(s.Text) - from - (len(part) - len(trimmed))
+	s.Text += strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	for i := start; i < end; i++ {
+		if matched[i] {
+			s.Highlights = append(s.Highlights, bookmark.Range{Start: tokens[i].Start + offset, End: tokens[i].End + offset})
+		}
+	}
+	if end < len(tokens) {
+		s.Text += ellipsis
+	}
+	return s, true
+}
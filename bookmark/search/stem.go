@@ -0,0 +1,200 @@
+package search
+
+import "strings"
+
+// Stem reduces an English word to its stem with the Porter stemming
+// algorithm, so that "operator", "operators" and "operating" all become
+// "oper". Words are expected in lowercase. Words that are not made of the
+// letters a to z, and words of two letters or less, are returned as is.
+func Stem(word string) string {
+	if len(word) <= 2 || strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+		return word
+	}
+	w := step1a(word)
+	w = step1b(w)
+	w = step1c(w)
+	w = replaceSuffix(w, step2)
+	w = replaceSuffix(w, step3)
+	w = step4(w)
+	return step5(w)
+}
+
+// suffix is a suffix and its replacement.
+type suffix struct {
+	from, to string
+}
+
+// step2 and step3 map suffixes to shorter ones. Only the first suffix that
+// matches is considered.
+//
+//nolint:gochecknoglobals // static tables of the algorithm
+var (
+	step2 = []suffix{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+		{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+		{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+		{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+		{"logi", "log"},
+	}
+	step3 = []suffix{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"},
+		{"ful", ""}, {"ness", ""},
+	}
+	// step4 are the suffixes that are removed from long stems.
+	step4Suffixes = []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+		"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+)
+
+// step1a removes plurals.
+func step1a(w string) string {
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// step1b removes -ed and -ing.
+func step1b(w string) string {
+	if stem, ok := strings.CutSuffix(w, "eed"); ok {
+		if measure(stem) > 0 {
+			return stem + "ee"
+		}
+		return w
+	}
+	stem, ok := strings.CutSuffix(w, "ed")
+	if !ok {
+		stem, ok = strings.CutSuffix(w, "ing")
+	}
+	if !ok || !hasVowel(stem) {
+		return w
+	}
+	switch {
+	case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+		return stem + "e"
+	case endsDouble(stem) && !strings.ContainsAny(stem[len(stem)-1:], "lsz"):
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+// step1c turns a final y into an i when the stem has a vowel.
+func step1c(w string) string {
+	if stem, ok := strings.CutSuffix(w, "y"); ok && hasVowel(stem) {
+		return stem + "i"
+	}
+	return w
+}
+
+// replaceSuffix replaces the first suffix that matches when the stem has a
+// vowel-consonant sequence.
+func replaceSuffix(w string, suffixes []suffix) string {
+	for _, s := range suffixes {
+		if stem, ok := strings.CutSuffix(w, s.from); ok {
+			if measure(stem) > 0 {
+				return stem + s.to
+			}
+			return w
+		}
+	}
+	return w
+}
+
+// step4 removes the first suffix that matches from stems with a measure of
+// more than one. -ion is only removed after an s or a t.
+func step4(w string) string {
+	for _, s := range step4Suffixes {
+		stem, ok := strings.CutSuffix(w, s)
+		if !ok {
+			continue
+		}
+		if s == "ion" && !strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "t") {
+			return w
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+// step5 removes a final e and turns a final ll into l for long stems.
+func step5(w string) string {
+	if stem, ok := strings.CutSuffix(w, "e"); ok {
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			w = stem
+		}
+	}
+	if strings.HasSuffix(w, "ll") && measure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// isConsonant reports whether the letter at i is a consonant. A y is a
+// consonant when it follows a vowel or starts the word.
+func isConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	default:
+		return true
+	}
+}
+
+// measure counts the vowel-consonant sequences of a word, which is m in
+// [C](VC){m}[V].
+func measure(w string) int {
+	var m int
+	vowel := false
+	for i := range len(w) {
+		if isConsonant(w, i) {
+			if vowel {
+				m++
+			}
+			vowel = false
+		} else {
+			vowel = true
+		}
+	}
+	return m
+}
+
+// hasVowel reports whether a word has a vowel.
+func hasVowel(w string) bool {
+	for i := range len(w) {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDouble reports whether a word ends with a double consonant.
+func endsDouble(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports whether a word ends with consonant-vowel-consonant where
+// the last consonant is not w, x or y, like hop.
+func endsCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-1) || isConsonant(w, n-2) || !isConsonant(w, n-3) {
+		return false
+	}
+	return !strings.ContainsAny(w[n-1:], "wxy")
+}
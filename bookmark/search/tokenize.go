@@ -0,0 +1,73 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Token is a word of a text.
+type Token struct {
+	// Term is the word folded and stemmed, as it is indexed.
+	Term string
+	// Start and End are the byte offsets of the word in the text.
+	Start, End int
+}
+
+// Tokenize splits a text into words. Words are runs of letters and digits,
+// their terms are case folded, stripped of diacritics and stemmed, so
+// "Café" and "cafe" are the same term, as are "Operators" and "operating".
+func Tokenize(s string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range s {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, Token{Term: Stem(fold(s[start:i])), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: Stem(fold(s[start:])), Start: start, End: len(s)})
+	}
+	return tokens
+}
+
+// isWordRune reports whether a rune is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// fold case folds a word and removes its diacritics.
+func fold(word string) string {
+	if isASCII(word) {
+		return strings.ToLower(word)
+	}
+	// transformers keep state, so they are not shared
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), cases.Fold(), norm.NFC)
+	folded, _, err := transform.String(t, word)
+	if err != nil {
+		return strings.ToLower(word)
+	}
+	return folded
+}
+
+// isASCII reports whether a string only has ASCII characters.
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package bookmark_test
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/DWethmar/bookmarks/bookmark/search"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLibrary_Search_index(t *testing.T) {
+	fetcher := bookmark.FetcherFunc(func(_ context.Context, url string) (*bookmark.Metadata, error) {
+		return &bookmark.Metadata{Title: "Page at " + url, Text: "Operators reconcile custom resources in Kubernetes."}, nil
+	})
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store,
+		bookmark.WithFetcher(fetcher), bookmark.WithIndex(search.New()))
+	ctx := context.Background()
+	page := &bookmark.Bookmark{Content: "https://example.com/operators", Tags: []string{"k8s"}}
+	note := &bookmark.Bookmark{Title: "Operator notes", Content: "Write an operator for backups"}
+	for _, b := range []*bookmark.Bookmark{page, note} {
+		if err := lib.Add(ctx, b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+	}
+	ids := func(t *testing.T, query string) []string {
+		t.Helper()
+		results, err := lib.Search(query, bookmark.SearchOptions{})
+		if err != nil {
+			t.Fatalf("Library.Search() error = %v", err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Bookmark.ID)
+			if r.Score <= 0 || len(r.Snippets) == 0 {
+				t.Errorf("Library.Search() result %s has score %v and snippets %v", r.Bookmark.ID, r.Score, r.Snippets)
+			}
+		}
+		return ids
+	}
+
+	t.Run("search should rank added bookmarks", func(t *testing.T) {
+		if diff := cmp.Diff([]string{note.ID, page.ID}, ids(t, "operators")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{page.ID}, ids(t, "kubernetes operator")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("search should filter tags", func(t *testing.T) {
+		if diff := cmp.Diff([]string{page.ID}, ids(t, "tag:k8s operator")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("search should combine the index with the query language", func(t *testing.T) {
+		if diff := cmp.Diff([]string{note.ID}, ids(t, "operators -tag:k8s")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{page.ID}, ids(t, "kubernetes OR title:missing")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("update should reindex the bookmark", func(t *testing.T) {
+		content := "Restore backups with velero"
+		if _, err := lib.Update(note.ID, &bookmark.Patch{Content: &content}); err != nil {
+			t.Fatalf("Library.Update() error = %v", err)
+		}
+		if diff := cmp.Diff([]string{note.ID}, ids(t, "velero")); diff != "" {
+			t.Errorf("Library.Search() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("delete should remove the bookmark from the index", func(t *testing.T) {
+		if err := lib.Delete(page.ID); err != nil {
+			t.Fatalf("Library.Delete() error = %v", err)
+		}
+		if got := ids(t, "kubernetes"); len(got) != 0 {
+			t.Errorf("Library.Search() = %v, want no results", got)
+		}
+	})
+}
+
+func TestLibrary_IndexOutdated(t *testing.T) {
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store, bookmark.WithIndex(search.New()))
+	if !lib.IndexOutdated() {
+		t.Fatal("IndexOutdated() = false, want a new index to be outdated")
+	}
+	if _, err := lib.Reindex(); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	b := &bookmark.Bookmark{Title: "Note", Content: "A note"}
+	if err := lib.Add(t.Context(), b); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := lib.Tag(b.ID, "notes"); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	if lib.IndexOutdated() {
+		t.Fatal("IndexOutdated() = true, want the changes of the library to keep the index in sync")
+	}
+
+	// another process changes the store
+	other := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+	if err := other.Add(t.Context(), &bookmark.Bookmark{Title: "Other", Content: "Another note"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if !lib.IndexOutdated() {
+		t.Fatal("IndexOutdated() = false, want the index to miss the change of the other library")
+	}
+	if err := lib.Delete(b.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if !lib.IndexOutdated() {
+		t.Fatal("IndexOutdated() = false, want the index to stay outdated")
+	}
+	if _, err := lib.Reindex(); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	if lib.IndexOutdated() {
+		t.Error("IndexOutdated() = true after Reindex()")
+	}
+}
+
+func TestLibrary_Search_ranking(t *testing.T) {
+	store := json.NewStore(path.Join(t.TempDir(), "test.json"))
+	for _, b := range []*bookmark.Bookmark{
+		{ID: "1", Title: "Notes", Content: "look at github later"},
+		{ID: "2", Title: "GitHub - foo", Content: "https://github.com/foo"},
+		{ID: "3", Title: "GitHub", Content: "https://github.com"},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), store)
+
+	tests := []struct {
+		name        string
+		query       string
+		mode        query.Mode
+		wantIDs     []string
+		wantMatches []bookmark.Match
+	}{
+		{
+			name:    "substring",
+			query:   "github",
+			wantIDs: []string{"3", "2", "1"},
+			wantMatches: []bookmark.Match{
+				{Field: "title", Ranges: []bookmark.Range{{Start: 0, End: 6}}},
+				{Field: "content", Ranges: []bookmark.Range{{Start: 8, End: 14}}},
+			},
+		},
+		{
+			name:    "fuzzy",
+			query:   "gthb",
+			mode:    query.ModeFuzzy,
+			wantIDs: []string{"3", "2", "1"},
+			wantMatches: []bookmark.Match{
+				{Field: "title", Ranges: []bookmark.Range{{Start: 0, End: 1}, {Start: 2, End: 4}, {Start: 5, End: 6}}},
+				{Field: "content", Ranges: []bookmark.Range{{Start: 8, End: 9}, {Start: 10, End: 12}, {Start: 13, End: 14}}},
+			},
+		},
+		{
+			name:    "regex",
+			query:   "git(hub|lab)",
+			mode:    query.ModeRegex,
+			wantIDs: []string{"3", "2", "1"},
+			wantMatches: []bookmark.Match{
+				{Field: "title", Ranges: []bookmark.Range{{Start: 0, End: 6}}},
+				{Field: "content", Ranges: []bookmark.Range{{Start: 8, End: 14}}},
+			},
+		},
+		{
+			name:    "terms",
+			query:   "git foo",
+			wantIDs: []string{"2"},
+			wantMatches: []bookmark.Match{
+				{Field: "title", Ranges: []bookmark.Range{{Start: 0, End: 3}, {Start: 9, End: 12}}},
+				{Field: "content", Ranges: []bookmark.Range{{Start: 8, End: 11}, {Start: 19, End: 22}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("search should rank better matches first: "+tt.name, func(t *testing.T) {
+			results, err := lib.Search(tt.query, bookmark.SearchOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatalf("Library.Search() error = %v", err)
+			}
+			var ids []string
+			for _, r := range results {
+				ids = append(ids, r.Bookmark.ID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Fatalf("Library.Search() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantMatches, results[0].Matches); diff != "" {
+				t.Errorf("Library.Search() matches mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package bookmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrNoArchiver is returned when archiving without an archiver.
+var ErrNoArchiver = errors.New("no archiver configured")
+
+// Snapshot is an archived copy of the page of a bookmark.
+type Snapshot struct {
+	// Hash is the hex encoded SHA-256 of the snapshot. Snapshots are stored
+	// by their hash, so equal pages are stored once.
+	Hash string
+	// Path is the path of the snapshot file.
+	Path string
+	// Format is how the page was archived, like html or text.
+	Format     string
+	ArchivedAt time.Time
+}
+
+// Archiver archives the page at a URL.
+type Archiver interface {
+	Archive(ctx context.Context, url string) (*Snapshot, error)
+}
+
+// WithArchiver sets the archiver used to archive the pages of bookmarks.
+func WithArchiver(a Archiver) Option {
+	return func(l *Library) {
+		l.archiver = a
+	}
+}
+
+// WithArchiveOnAdd archives the page of every bookmark of a URL that is
+// added. It needs an archiver and does nothing offline.
+func WithArchiveOnAdd() Option {
+	return func(l *Library) {
+		l.archiveOnAdd = true
+	}
+}
+
+// Archive archives the page of the bookmark with the given ID and records
+// the snapshot on the bookmark.
+func (l *Library) Archive(ctx context.Context, id string) (*Bookmark, error) {
+	if l.archiver == nil {
+		return nil, ErrNoArchiver
+	}
+	b, err := l.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !b.HasURL() {
+		return nil, fmt.Errorf("content of %s is not a url", b.ID)
+	}
+	s, err := l.archive(ctx, b.Content)
+	if err != nil {
+		return nil, err
+	}
+	return l.Update(id, &Patch{Snapshot: s})
+}
+
+// archive archives the page at url.
+func (l *Library) archive(ctx context.Context, url string) (*Snapshot, error) {
+	s, err := l.archiver.Archive(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("could not archive %s: %w", url, err)
+	}
+	s.ArchivedAt = l.now()
+	l.logger.DebugContext(ctx, "archived page", slog.String("url", url), slog.String("path", s.Path))
+	return s, nil
+}
@@ -0,0 +1,97 @@
+package bookmark_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/google/go-cmp/cmp"
+)
+
+// archiverFunc is a function that implements bookmark.Archiver.
+type archiverFunc func(ctx context.Context, url string) (*bookmark.Snapshot, error)
+
+func (f archiverFunc) Archive(ctx context.Context, url string) (*bookmark.Snapshot, error) {
+	return f(ctx, url)
+}
+
+func TestLibrary_Archive(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	archiver := archiverFunc(func(_ context.Context, url string) (*bookmark.Snapshot, error) {
+		if url == "https://example.com/fail" {
+			return nil, errors.New("not found")
+		}
+		return &bookmark.Snapshot{Hash: "abc", Path: "/archive/ab/abc.html", Format: "html"}, nil
+	})
+	fetcher := bookmark.FetcherFunc(func(context.Context, string) (*bookmark.Metadata, error) {
+		return &bookmark.Metadata{Title: "Page"}, nil
+	})
+	newLibrary := func(t *testing.T, opts ...bookmark.Option) *bookmark.Library {
+		t.Helper()
+		return bookmark.NewLibrary(
+			slog.New(slog.DiscardHandler),
+			json.NewStore(path.Join(t.TempDir(), "test.json")),
+			append([]bookmark.Option{
+				bookmark.WithFetcher(fetcher),
+				bookmark.WithArchiver(archiver),
+				bookmark.WithClock(func() time.Time { return now }),
+			}, opts...)...,
+		)
+	}
+	want := bookmark.Snapshot{Hash: "abc", Path: "/archive/ab/abc.html", Format: "html", ArchivedAt: now}
+
+	t.Run("archive should record the snapshot on the bookmark", func(t *testing.T) {
+		lib := newLibrary(t)
+		b := &bookmark.Bookmark{Content: "https://example.com"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if b.Snapshot != (bookmark.Snapshot{}) {
+			t.Errorf("Library.Add() archived %+v without archive on add", b.Snapshot)
+		}
+		if _, err := lib.Archive(t.Context(), b.ID); err != nil {
+			t.Fatalf("Library.Archive() error = %v", err)
+		}
+		stored, err := lib.Get(b.ID)
+		if err != nil {
+			t.Fatalf("Library.Get() error = %v", err)
+		}
+		if diff := cmp.Diff(want, stored.Snapshot); diff != "" {
+			t.Errorf("Library.Archive() snapshot mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("archive on add should archive new bookmarks", func(t *testing.T) {
+		lib := newLibrary(t, bookmark.WithArchiveOnAdd())
+		b := &bookmark.Bookmark{Content: "https://example.com"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if diff := cmp.Diff(want, b.Snapshot); diff != "" {
+			t.Errorf("Library.Add() snapshot mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("archive on add should still add when archiving fails", func(t *testing.T) {
+		lib := newLibrary(t, bookmark.WithArchiveOnAdd())
+		b := &bookmark.Bookmark{Content: "https://example.com/fail"}
+		if err := lib.Add(t.Context(), b); err != nil {
+			t.Fatalf("Library.Add() error = %v", err)
+		}
+		if b.ID == "" || b.Snapshot != (bookmark.Snapshot{}) {
+			t.Errorf("Library.Add() = %+v, want a bookmark without snapshot", b)
+		}
+	})
+
+	t.Run("archive should fail without an archiver", func(t *testing.T) {
+		lib := bookmark.NewLibrary(slog.New(slog.DiscardHandler), json.NewStore(path.Join(t.TempDir(), "test.json")))
+		if _, err := lib.Archive(t.Context(), "id"); !errors.Is(err, bookmark.ErrNoArchiver) {
+			t.Errorf("Library.Archive() error = %v, want %v", err, bookmark.ErrNoArchiver)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+)
+
+var _ bookmark.CollectionStore = &Store{}
+
+// AddCollection implements bookmark.CollectionStore.
+func (s *Store) AddCollection(c *bookmark.Collection) error {
+	return s.tx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO collections (path, created_at) VALUES (?, ?)`,
+			c.Path, timeValue(c.CreatedAt))
+		return err
+	})
+}
+
+// Collections implements bookmark.CollectionStore.
+func (s *Store) Collections() ([]*bookmark.Collection, error) {
+	var collections []*bookmark.Collection
+	err := s.tx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT path, created_at FROM collections`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			c := &bookmark.Collection{}
+			var createdAt sql.NullInt64
+			if err = rows.Scan(&c.Path, &createdAt); err != nil {
+				return err
+			}
+			c.CreatedAt = timeFrom(createdAt)
+			collections = append(collections, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	// SQL sorts "dev-tools" between "dev" and "dev/go"
+	slices.SortFunc(collections, func(a, b *bookmark.Collection) int {
+		return bookmark.CompareCollections(a.Path, b.Path)
+	})
+	return collections, nil
+}
+
+// DeleteCollection implements bookmark.CollectionStore.
+func (s *Store) DeleteCollection(path string) error {
+	return s.tx(func(tx *sql.Tx) error {
+		r, err := tx.Exec(`DELETE FROM collections WHERE path = ?`, path)
+		if err != nil {
+			return err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %s", bookmark.ErrCollectionNotFound, path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are the schema migrations of the database. The schema version
+// is tracked in PRAGMA user_version, so migrations must only be appended.
+//
+//nolint:gochecknoglobals // the list of migrations is static
+var migrations = []string{
+	// 1: bookmarks and tags
+	`CREATE TABLE bookmarks (
+		id         TEXT PRIMARY KEY,
+		title      TEXT NOT NULL,
+		content    TEXT NOT NULL,
+		created_at INTEGER,
+		updated_at INTEGER
+	);
+	CREATE INDEX bookmarks_title_idx ON bookmarks (title);
+	CREATE INDEX bookmarks_created_at_idx ON bookmarks (created_at);
+	CREATE TABLE bookmark_tags (
+		bookmark_id TEXT NOT NULL REFERENCES bookmarks (id) ON DELETE CASCADE,
+		tag         TEXT NOT NULL,
+		PRIMARY KEY (bookmark_id, tag)
+	);
+	CREATE INDEX bookmark_tags_tag_idx ON bookmark_tags (tag);`,
+	// 2: page metadata
+	`ALTER TABLE bookmarks ADD COLUMN meta_title TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN site_name TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN favicon_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN language TEXT NOT NULL DEFAULT '';`,
+	// 3: pending metadata
+	`ALTER TABLE bookmarks ADD COLUMN pending_metadata INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX bookmarks_pending_metadata_idx ON bookmarks (pending_metadata) WHERE pending_metadata;`,
+	// 4: time the metadata was fetched
+	`ALTER TABLE bookmarks ADD COLUMN fetched_at INTEGER;`,
+	// 5: link health
+	`ALTER TABLE bookmarks ADD COLUMN status_code INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE bookmarks ADD COLUMN check_error TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN final_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN permanent_redirect INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE bookmarks ADD COLUMN failures INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE bookmarks ADD COLUMN checked_at INTEGER;`,
+	// 6: page snapshots
+	`ALTER TABLE bookmarks ADD COLUMN snapshot_hash TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN snapshot_path TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN snapshot_format TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmarks ADD COLUMN archived_at INTEGER;`,
+	// 7: readable text of pages
+	`ALTER TABLE bookmarks ADD COLUMN text TEXT NOT NULL DEFAULT '';`,
+	// 8: saved searches
+	`CREATE TABLE saved_searches (
+		name       TEXT PRIMARY KEY,
+		query      TEXT NOT NULL,
+		mode       TEXT NOT NULL DEFAULT '',
+		created_at INTEGER
+	);`,
+	// 9: collections
+	`ALTER TABLE bookmarks ADD COLUMN collection TEXT NOT NULL DEFAULT '';
+	CREATE INDEX bookmarks_collection_idx ON bookmarks (collection);
+	CREATE TABLE collections (
+		path       TEXT PRIMARY KEY,
+		created_at INTEGER
+	);`,
+	// 10: generation that counts the changes of bookmarks
+	`CREATE TABLE generation (n INTEGER NOT NULL);
+	INSERT INTO generation (n) VALUES (0);
+	CREATE TRIGGER bookmarks_insert_generation AFTER INSERT ON bookmarks
+	BEGIN UPDATE generation SET n = n + 1; END;
+	CREATE TRIGGER bookmarks_update_generation AFTER UPDATE ON bookmarks
+	BEGIN UPDATE generation SET n = n + 1; END;
+	CREATE TRIGGER bookmarks_delete_generation AFTER DELETE ON bookmarks
+	BEGIN UPDATE generation SET n = n + 1; END;`,
+}
+
+// migrate brings the database schema up to date. Every migration runs in
+// its own transaction together with the version bump.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not support placeholders
+		if _, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	sqlitedriver "modernc.org/sqlite"
+)
+
+var _ bookmark.Querier = &Store{}
+
+// The functions that queries compile to match the same way as bookmarks
+// that are searched in memory.
+//
+//nolint:gochecknoinits // functions must be registered before connecting
+func init() {
+	sqlitedriver.MustRegisterDeterministicScalarFunction("bookmarks_match", 3,
+		func(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+			t := &query.Term{Value: text(args[1]), Mode: query.Mode(text(args[2]))}
+			_, ok := t.Match(text(args[0]))
+			return ok, nil
+		})
+	sqlitedriver.MustRegisterDeterministicScalarFunction("bookmarks_site", 2,
+		func(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return query.MatchSite(text(args[0]), text(args[1])), nil
+		})
+}
+
+// text returns the text of a value passed to a function.
+func text(v driver.Value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+// textColumns are the columns that words and phrases match, the page text
+// last.
+//
+//nolint:gochecknoglobals // static list of columns
+var textColumns = []string{"title", "content", "description", "site_name", "text"}
+
+// Query implements bookmark.Querier by compiling the expression to SQL.
+func (s *Store) Query(e query.Expr) ([]*bookmark.Bookmark, error) {
+	var c compiler
+	where := c.compile(e)
+	var bookmarks []*bookmark.Bookmark
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		bookmarks, err = list(tx, where, c.args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// compiler compiles query expressions to SQL conditions. The values in the
+// conditions are collected in args.
+type compiler struct {
+	args []any
+}
+
+// compile returns the condition of an expression. A nil expression matches
+// every bookmark.
+func (c *compiler) compile(e query.Expr) string {
+	switch e := e.(type) {
+	case nil:
+		return "1"
+	case *query.And:
+		return "(" + c.compile(e.Left) + " AND " + c.compile(e.Right) + ")"
+	case *query.Or:
+		return "(" + c.compile(e.Left) + " OR " + c.compile(e.Right) + ")"
+	case *query.Not:
+		return "NOT " + c.compile(e.Expr)
+	case *query.Term:
+		return c.term(e)
+	case *query.DateRange:
+		return c.dateRange(e)
+	case *query.IDs:
+		if len(e.IDs) == 0 {
+			return "0"
+		}
+		// a single JSON array stays below the limit on the number of
+		// parameters, however many bookmarks the index found
+		ids, err := json.Marshal(e.IDs)
+		if err != nil {
+			panic(fmt.Sprintf("sqlite: could not encode ids: %v", err))
+		}
+		c.args = append(c.args, string(ids))
+		return "id IN (SELECT value FROM json_each(?))"
+	default:
+		panic(fmt.Sprintf("sqlite: unknown query expression %T", e))
+	}
+}
+
+// term returns the condition of a term.
+func (c *compiler) term(t *query.Term) string {
+	switch t.Field {
+	case query.FieldTitle:
+		return c.match("title", t)
+	case query.FieldURL:
+		return c.match("content", t)
+	case query.FieldSite:
+		c.args = append(c.args, t.Value)
+		return "bookmarks_site(content, ?)"
+	case query.FieldTag:
+		if t.Value == query.AnyTag {
+			return "EXISTS (SELECT 1 FROM bookmark_tags WHERE bookmark_id = bookmarks.id)"
+		}
+		tags := bookmark.NormalizeTags([]string{t.Value})
+		if len(tags) == 0 {
+			return "1"
+		}
+		c.args = append(c.args, tags[0])
+		return "EXISTS (SELECT 1 FROM bookmark_tags WHERE bookmark_id = bookmarks.id AND tag = ?)"
+	default:
+		columns := textColumns
+		if t.Mode == query.ModeFuzzy {
+			// like bookmarks in memory, fuzzy words skip the page text
+			columns = columns[:len(columns)-1]
+		}
+		conditions := make([]string, 0, len(columns))
+		for _, column := range columns {
+			conditions = append(conditions, c.match(column, t))
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")"
+	}
+}
+
+// match returns the condition that the term matches a column.
+func (c *compiler) match(column string, t *query.Term) string {
+	c.args = append(c.args, t.Value, string(t.Mode))
+	return "bookmarks_match(" + column + ", ?, ?)"
+}
+
+// dateRange returns the condition of a date range. Bookmarks without the
+// time do not match.
+func (c *compiler) dateRange(r *query.DateRange) string {
+	column := "created_at"
+	if r.Field == query.FieldUpdated {
+		column = "updated_at"
+	}
+	conditions := []string{column + " IS NOT NULL"}
+	if !r.From.IsZero() {
+		c.args = append(c.args, r.From.UnixNano())
+		conditions = append(conditions, column+" >= ?")
+	}
+	if !r.To.IsZero() {
+		c.args = append(c.args, r.To.UnixNano())
+		conditions = append(conditions, column+" < ?")
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
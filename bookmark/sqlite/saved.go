@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+)
+
+var _ bookmark.SearchStore = &Store{}
+
+// SaveSearch implements bookmark.SearchStore.
+func (s *Store) SaveSearch(search *bookmark.SavedSearch) error {
+	return s.tx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO saved_searches (name, query, mode, created_at) VALUES (?, ?, ?, ?)`,
+			search.Name, search.Query, string(search.Mode), timeValue(search.CreatedAt))
+		return err
+	})
+}
+
+// Searches implements bookmark.SearchStore.
+func (s *Store) Searches() ([]*bookmark.SavedSearch, error) {
+	var searches []*bookmark.SavedSearch
+	err := s.tx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT name, query, mode, created_at FROM saved_searches ORDER BY name`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			search := &bookmark.SavedSearch{}
+			var mode string
+			var createdAt sql.NullInt64
+			if err = rows.Scan(&search.Name, &search.Query, &mode, &createdAt); err != nil {
+				return err
+			}
+			search.Mode = query.Mode(mode)
+			search.CreatedAt = timeFrom(createdAt)
+			searches = append(searches, search)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// DeleteSearch implements bookmark.SearchStore.
+func (s *Store) DeleteSearch(name string) error {
+	return s.tx(func(tx *sql.Tx) error {
+		r, err := tx.Exec(`DELETE FROM saved_searches WHERE name = ?`, name)
+		if err != nil {
+			return err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %s", bookmark.ErrSearchNotFound, name)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,357 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	_ "modernc.org/sqlite" // pure Go SQLite driver
+)
+
+var _ bookmark.Store = &Store{}
+
+var _ bookmark.BatchStore = &Store{}
+
+var _ bookmark.VersionedStore = &Store{}
+
+var (
+	// ErrNotFound is returned when a bookmark is not found.
+	ErrNotFound = bookmark.ErrNotFound
+)
+
+// Store is a struct that represents a SQLite store.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens the SQLite database at the given path, creating it if it
+// does not exist, and migrates it to the latest schema.
+func NewStore(filePath string) (*Store, error) {
+	// The pragmas are applied to every connection in the pool. Transactions
+	// take the write lock up front, so read-modify-write cycles of
+	// concurrent processes wait for each other instead of failing.
+	dsn := url.URL{
+		Scheme:   "file",
+		OmitHost: true,
+		Path:     uriPath(filePath),
+		RawQuery: "_txlock=immediate&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	if err = migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not migrate database: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// uriPath returns the path of a file in a SQLite URI. Paths with a volume
+// name, like C:\bookmarks.db, start with a slash.
+func uriPath(filePath string) string {
+	p := filepath.ToSlash(filePath)
+	if filepath.VolumeName(filePath) != "" {
+		p = "/" + p
+	}
+	return p
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add implements bookmark.Store.
+func (s *Store) Add(b *bookmark.Bookmark) error {
+	return s.AddAll([]*bookmark.Bookmark{b})
+}
+
+// AddAll implements bookmark.BatchStore. The bookmarks are inserted in a
+// single transaction.
+func (s *Store) AddAll(bookmarks []*bookmark.Bookmark) error {
+	return s.tx(func(tx *sql.Tx) error {
+		for _, b := range bookmarks {
+			if b.ID == "" {
+				b.ID = bookmark.NewID()
+			}
+			if _, err := tx.Exec(insertBookmark, bookmarkValues(b)...); err != nil {
+				return err
+			}
+			if err := setTags(tx, b.ID, b.Tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get implements bookmark.Store.
+func (s *Store) Get(id string) (*bookmark.Bookmark, error) {
+	var b *bookmark.Bookmark
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		b, err = get(tx, id)
+		return err
+	})
+	return b, err
+}
+
+// Update implements bookmark.Store.
+func (s *Store) Update(id string, p *bookmark.Patch) (*bookmark.Bookmark, error) {
+	var b *bookmark.Bookmark
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		if b, err = get(tx, id); err != nil {
+			return err
+		}
+		p.Apply(b)
+		if _, err = tx.Exec(updateBookmark, append(bookmarkValues(b)[1:], b.ID)...); err != nil {
+			return err
+		}
+		return setTags(tx, b.ID, b.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// List implements bookmark.Store.
+func (s *Store) List() ([]*bookmark.Bookmark, error) {
+	var bookmarks []*bookmark.Bookmark
+	err := s.tx(func(tx *sql.Tx) error {
+		var err error
+		bookmarks, err = list(tx, "1")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// Delete implements bookmark.Store.
+func (s *Store) Delete(id string) error {
+	return s.tx(func(tx *sql.Tx) error {
+		// tags are removed by the foreign key cascade
+		r, err := tx.Exec(`DELETE FROM bookmarks WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Version implements bookmark.VersionedStore. It is the number of changes
+// to bookmarks, which triggers count.
+func (s *Store) Version() (string, error) {
+	var n int64
+	if err := s.db.QueryRow(`SELECT n FROM generation`).Scan(&n); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n, 10), nil
+}
+
+// tx runs fn in a transaction. The transaction is committed when fn
+// succeeds and rolled back otherwise.
+func (s *Store) tx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		return errors.Join(err, rollback(tx))
+	}
+	return tx.Commit()
+}
+
+// rollback rolls back the transaction, ignoring transactions that are
+// already done.
+func rollback(tx *sql.Tx) error {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// bookmarkColumns are the columns scanned by scanBookmark and written by
+// bookmarkValues, in that order.
+const bookmarkColumns = `id, title, content, created_at, updated_at,
+	meta_title, description, site_name, canonical_url, favicon_url, image_url, language,
+	pending_metadata, fetched_at,
+	status_code, check_error, final_url, permanent_redirect, failures, checked_at,
+	snapshot_hash, snapshot_path, snapshot_format, archived_at,
+	text, collection`
+
+const (
+	insertBookmark = `INSERT INTO bookmarks (` + bookmarkColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	updateBookmark = `UPDATE bookmarks SET
+		title = ?, content = ?, created_at = ?, updated_at = ?,
+		meta_title = ?, description = ?, site_name = ?, canonical_url = ?, favicon_url = ?, image_url = ?, language = ?,
+		pending_metadata = ?, fetched_at = ?,
+		status_code = ?, check_error = ?, final_url = ?, permanent_redirect = ?, failures = ?, checked_at = ?,
+		snapshot_hash = ?, snapshot_path = ?, snapshot_format = ?, archived_at = ?,
+		text = ?, collection = ?
+		WHERE id = ?`
+)
+
+// bookmarkValues returns the values of the bookmarkColumns of a bookmark.
+func bookmarkValues(b *bookmark.Bookmark) []any {
+	m := b.Metadata
+	h := b.Health
+	s := b.Snapshot
+	return []any{
+		b.ID, b.Title, b.Content, timeValue(b.CreatedAt), timeValue(b.UpdatedAt),
+		m.Title, m.Description, m.SiteName, m.CanonicalURL, m.FaviconURL, m.ImageURL, m.Language,
+		b.PendingMetadata, timeValue(m.FetchedAt),
+		h.StatusCode, h.Error, h.FinalURL, h.PermanentRedirect, h.Failures, timeValue(h.CheckedAt),
+		s.Hash, s.Path, s.Format, timeValue(s.ArchivedAt),
+		m.Text, b.Collection,
+	}
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBookmark scans the bookmarkColumns into a bookmark.
+func scanBookmark(row scanner) (*bookmark.Bookmark, error) {
+	b := &bookmark.Bookmark{}
+	m := &b.Metadata
+	h := &b.Health
+	s := &b.Snapshot
+	var createdAt, updatedAt, fetchedAt, checkedAt, archivedAt sql.NullInt64
+	if err := row.Scan(
+		&b.ID, &b.Title, &b.Content, &createdAt, &updatedAt,
+		&m.Title, &m.Description, &m.SiteName, &m.CanonicalURL, &m.FaviconURL, &m.ImageURL, &m.Language,
+		&b.PendingMetadata, &fetchedAt,
+		&h.StatusCode, &h.Error, &h.FinalURL, &h.PermanentRedirect, &h.Failures, &checkedAt,
+		&s.Hash, &s.Path, &s.Format, &archivedAt,
+		&m.Text, &b.Collection,
+	); err != nil {
+		return nil, err
+	}
+	b.CreatedAt = timeFrom(createdAt)
+	b.UpdatedAt = timeFrom(updatedAt)
+	m.FetchedAt = timeFrom(fetchedAt)
+	h.CheckedAt = timeFrom(checkedAt)
+	s.ArchivedAt = timeFrom(archivedAt)
+	return b, nil
+}
+
+// get gets a single bookmark including its tags.
+func get(tx *sql.Tx, id string) (*bookmark.Bookmark, error) {
+	b, err := scanBookmark(tx.QueryRow(`SELECT `+bookmarkColumns+` FROM bookmarks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`SELECT tag FROM bookmark_tags WHERE bookmark_id = ? ORDER BY tag`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		b.Tags = append(b.Tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// list lists the bookmarks that meet the condition, including their tags.
+func list(tx *sql.Tx, where string, args ...any) ([]*bookmark.Bookmark, error) {
+	rows, err := tx.Query(`SELECT `+bookmarkColumns+` FROM bookmarks WHERE `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bookmarks []*bookmark.Bookmark
+	byID := map[string]*bookmark.Bookmark{}
+	for rows.Next() {
+		b, sErr := scanBookmark(rows)
+		if sErr != nil {
+			return nil, sErr
+		}
+		bookmarks = append(bookmarks, b)
+		byID[b.ID] = b
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = loadTags(tx, byID); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// loadTags loads the tags of all bookmarks in byID.
+func loadTags(tx *sql.Tx, byID map[string]*bookmark.Bookmark) error {
+	rows, err := tx.Query(`SELECT bookmark_id, tag FROM bookmark_tags ORDER BY bookmark_id, tag`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if err = rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if b, ok := byID[id]; ok {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+// setTags replaces the tags of a bookmark.
+func setTags(tx *sql.Tx, id string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM bookmark_tags WHERE bookmark_id = ?`, id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO bookmark_tags (bookmark_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeValue converts a time to the value stored in the database. Times are
+// stored as unix nanoseconds, the zero time is stored as NULL.
+func timeValue(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+// timeFrom converts a value stored in the database back to a time.
+func timeFrom(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, v.Int64).UTC()
+}
@@ -0,0 +1,495 @@
+package sqlite_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/DWethmar/bookmarks/bookmark/sqlite"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newStore(t *testing.T) *sqlite.Store {
+	t.Helper()
+	store, err := sqlite.NewStore(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func addBookmarks(t *testing.T, store *sqlite.Store, n int) []*bookmark.Bookmark {
+	t.Helper()
+	var bookmarks []*bookmark.Bookmark
+	for i := range n {
+		b := &bookmark.Bookmark{
+			ID:         fmt.Sprintf("01JQ000000000000000000000%d", i),
+			Title:      fmt.Sprintf("Test %d", i),
+			Content:    fmt.Sprintf("Test %d", i),
+			Tags:       []string{fmt.Sprintf("tag%d", i), "test"},
+			Collection: fmt.Sprintf("test/%d", i),
+			Metadata: bookmark.Metadata{
+				Title:       fmt.Sprintf("Page %d", i),
+				Description: fmt.Sprintf("Description %d", i),
+				SiteName:    "Example",
+				Language:    "en",
+				Text:        fmt.Sprintf("Text %d", i),
+				FetchedAt:   time.Date(2021, 1, 1, 0, 0, 0, i, time.UTC),
+			},
+			Health: bookmark.Health{
+				StatusCode: 404,
+				FinalURL:   fmt.Sprintf("Test %d", i),
+				Failures:   i,
+				CheckedAt:  time.Date(2021, 1, 1, 0, 0, 0, i, time.UTC),
+			},
+			CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, i, time.UTC),
+		}
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks
+}
+
+func TestNewStore(t *testing.T) {
+	t.Run("reopening should keep the bookmarks", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "test.db")
+		store, err := sqlite.NewStore(filePath)
+		if err != nil {
+			t.Fatalf("NewStore() error = %v", err)
+		}
+		if err = store.Add(&bookmark.Bookmark{Title: "Test 1", Content: "Test 1"}); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		if err = store.Close(); err != nil {
+			t.Fatalf("Store.Close() error = %v", err)
+		}
+		store, err = sqlite.NewStore(filePath)
+		if err != nil {
+			t.Fatalf("NewStore() error = %v", err)
+		}
+		defer store.Close()
+		bookmarks, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if len(bookmarks) != 1 {
+			t.Errorf("Store.List() returned %d bookmarks, want 1", len(bookmarks))
+		}
+	})
+
+	t.Run("paths with uri characters should open the given file", func(t *testing.T) {
+		dir := path.Join(t.TempDir(), "a?b#c%20d e")
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("os.Mkdir() error = %v", err)
+		}
+		filePath := path.Join(dir, "test.db")
+		store, err := sqlite.NewStore(filePath)
+		if err != nil {
+			t.Fatalf("NewStore() error = %v", err)
+		}
+		defer store.Close()
+		if err = store.Add(&bookmark.Bookmark{Title: "Test 1", Content: "Test 1"}); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		if _, err = os.Stat(filePath); err != nil {
+			t.Errorf("database is not at %s: %v", filePath, err)
+		}
+	})
+}
+
+func TestStore_Add(t *testing.T) {
+	t.Run("add should assign an id to a bookmark without one", func(t *testing.T) {
+		store := newStore(t)
+		b := &bookmark.Bookmark{Title: "Test 1", Content: "Test 1"}
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+		if b.ID == "" {
+			t.Fatal("Store.Add() did not assign an id")
+		}
+	})
+
+	t.Run("add should fail for a duplicate id", func(t *testing.T) {
+		store := newStore(t)
+		addBookmarks(t, store, 1)
+		if err := store.Add(&bookmark.Bookmark{ID: "01JQ0000000000000000000000"}); err == nil {
+			t.Error("Store.Add() expected an error")
+		}
+	})
+}
+
+func TestStore_AddAll(t *testing.T) {
+	t.Run("add all should add every bookmark", func(t *testing.T) {
+		store := newStore(t)
+		bookmarks := []*bookmark.Bookmark{
+			{Title: "Test 1", Content: "Test 1", Tags: []string{"a"}},
+			{Title: "Test 2", Content: "Test 2", Tags: []string{"a", "b"}},
+		}
+		if err := store.AddAll(bookmarks); err != nil {
+			t.Fatalf("Store.AddAll() error = %v", err)
+		}
+		got, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if diff := cmp.Diff(bookmarks, got); diff != "" {
+			t.Errorf("Store.List() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("add all should add nothing when a bookmark fails", func(t *testing.T) {
+		store := newStore(t)
+		existing := addBookmarks(t, store, 1)
+		err := store.AddAll([]*bookmark.Bookmark{{Title: "New"}, {ID: existing[0].ID}})
+		if err == nil {
+			t.Fatal("Store.AddAll() expected an error")
+		}
+		got, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if diff := cmp.Diff(existing, got); diff != "" {
+			t.Errorf("Store.List() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestStore_Version(t *testing.T) {
+	store := newStore(t)
+	versions := map[string]bool{}
+	record := func(change string) {
+		t.Helper()
+		v, err := store.Version()
+		if err != nil {
+			t.Fatalf("Store.Version() error = %v", err)
+		}
+		if versions[v] {
+			t.Errorf("Store.Version() = %s after %s, want a new version", v, change)
+		}
+		versions[v] = true
+	}
+	record("opening")
+	b := addBookmarks(t, store, 1)[0]
+	record("add")
+	if _, err := store.Update(b.ID, &bookmark.Patch{AddTags: []string{"new"}}); err != nil {
+		t.Fatalf("Store.Update() error = %v", err)
+	}
+	record("update")
+	if err := store.Delete(b.ID); err != nil {
+		t.Fatalf("Store.Delete() error = %v", err)
+	}
+	record("delete")
+
+	if err := store.AddCollection(&bookmark.Collection{Path: "dev"}); err != nil {
+		t.Fatalf("Store.AddCollection() error = %v", err)
+	}
+	v, err := store.Version()
+	if err != nil {
+		t.Fatalf("Store.Version() error = %v", err)
+	}
+	if !versions[v] {
+		t.Errorf("Store.Version() = %s, want collections to keep the version", v)
+	}
+}
+
+func TestStore_Get(t *testing.T) {
+	t.Run("get should return the bookmark with the given id", func(t *testing.T) {
+		store := newStore(t)
+		want := addBookmarks(t, store, 2)[1]
+		got, err := store.Get(want.ID)
+		if err != nil {
+			t.Fatalf("Store.Get() error = %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Get() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Get("unknown"); !errors.Is(err, sqlite.ErrNotFound) {
+			t.Errorf("Store.Get() error = %v, want %v", err, sqlite.ErrNotFound)
+		}
+	})
+}
+
+func TestStore_Update(t *testing.T) {
+	t.Run("update should patch the bookmark", func(t *testing.T) {
+		store := newStore(t)
+		b := addBookmarks(t, store, 1)[0]
+		title := "Updated"
+		collection := "dev/go"
+		updatedAt := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+		got, err := store.Update(b.ID, &bookmark.Patch{
+			Title:      &title,
+			Collection: &collection,
+			AddTags:    []string{"new"},
+			RemoveTags: []string{"tag0"},
+			UpdatedAt:  updatedAt,
+		})
+		if err != nil {
+			t.Fatalf("Store.Update() error = %v", err)
+		}
+		want := &bookmark.Bookmark{
+			ID:         b.ID,
+			Title:      "Updated",
+			Content:    b.Content,
+			Tags:       []string{"new", "test"},
+			Collection: "dev/go",
+			Metadata:   b.Metadata,
+			Health:     b.Health,
+			CreatedAt:  b.CreatedAt,
+			UpdatedAt:  updatedAt,
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Update() mismatch (-want +got):\n%s", diff)
+		}
+		stored, err := store.Get(b.ID)
+		if err != nil {
+			t.Fatalf("Store.Get() error = %v", err)
+		}
+		if diff := cmp.Diff(want, stored); diff != "" {
+			t.Errorf("stored bookmark mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("update should set and clear pending metadata", func(t *testing.T) {
+		store := newStore(t)
+		b := addBookmarks(t, store, 1)[0]
+		for _, pending := range []bool{true, false} {
+			if _, err := store.Update(b.ID, &bookmark.Patch{PendingMetadata: &pending}); err != nil {
+				t.Fatalf("Store.Update() error = %v", err)
+			}
+			got, err := store.Get(b.ID)
+			if err != nil {
+				t.Fatalf("Store.Get() error = %v", err)
+			}
+			if got.PendingMetadata != pending {
+				t.Errorf("PendingMetadata = %v, want %v", got.PendingMetadata, pending)
+			}
+		}
+	})
+
+	t.Run("update should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Update("unknown", &bookmark.Patch{}); !errors.Is(err, sqlite.ErrNotFound) {
+			t.Errorf("Store.Update() error = %v, want %v", err, sqlite.ErrNotFound)
+		}
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	t.Run("list should return bookmarks in insertion order", func(t *testing.T) {
+		store := newStore(t)
+		want := addBookmarks(t, store, 3)
+		got, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.List() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestStore_Delete(t *testing.T) {
+	t.Run("delete should delete a bookmark and its tags", func(t *testing.T) {
+		store := newStore(t)
+		bookmarks := addBookmarks(t, store, 3)
+		if err := store.Delete(bookmarks[1].ID); err != nil {
+			t.Fatalf("Store.Delete() error = %v", err)
+		}
+		got, err := store.List()
+		if err != nil {
+			t.Fatalf("Store.List() error = %v", err)
+		}
+		want := []*bookmark.Bookmark{bookmarks[0], bookmarks[2]}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.List() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("delete should return ErrNotFound for an unknown id", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Delete("unknown"); !errors.Is(err, sqlite.ErrNotFound) {
+			t.Errorf("Store.Delete() error = %v, want %v", err, sqlite.ErrNotFound)
+		}
+	})
+}
+
+func TestStore_Query(t *testing.T) {
+	store := newStore(t)
+	for _, b := range []*bookmark.Bookmark{
+		{ID: "1", Title: "Go Blog", Content: "https://go.dev/blog", Tags: []string{"go"},
+			CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Title: "Go playground", Content: "https://go.dev/play", Tags: []string{"go", "tools"},
+			CreatedAt: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: "3", Title: "Rust blog", Content: "https://blog.rust-lang.org", Tags: []string{"rust"},
+			CreatedAt: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{ID: "4", Title: "Clusters", Content: "https://example.com/operators", Metadata: bookmark.Metadata{
+			Text: "A Kubernetes operator reconciles custom resources.",
+		}},
+	} {
+		if err := store.Add(b); err != nil {
+			t.Fatalf("Store.Add() error = %v", err)
+		}
+	}
+	all, err := store.List()
+	if err != nil {
+		t.Fatalf("Store.List() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		mode  query.Mode
+	}{
+		{query: ""},
+		{query: "blog"},
+		{query: "BLOG kubernetes"},
+		{query: "kubernetes"},
+		{query: "title:blog -tag:rust"},
+		{query: "-tag:*"},
+		{query: "url:PLAY OR tag:Rust"},
+		{query: "site:go.dev"},
+		{query: "site:rust-lang.org"},
+		{query: `"go blog"`},
+		{query: "(tag:go OR tag:rust) NOT blog"},
+		{query: "created:>2025-01-10"},
+		{query: "created:2024..2025-01"},
+		{query: "updated:today"},
+		{query: "NOT updated:today"},
+		{query: "gblg", mode: query.ModeFuzzy},
+		{query: "kubernetes", mode: query.ModeFuzzy},
+		{query: `"go blog" OR title:rst`, mode: query.ModeFuzzy},
+		{query: "^go", mode: query.ModeRegex},
+		{query: `url:"\.(dev|org)/" -blog`, mode: query.ModeRegex},
+	} {
+		mode := tt.mode
+		if mode == "" {
+			mode = query.ModeSubstring
+		}
+		t.Run("query should match like bookmarks in memory: "+string(mode)+" "+tt.query, func(t *testing.T) {
+			e, err := query.Parse(tt.query, mode, time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("query.Parse() error = %v", err)
+			}
+			var want []*bookmark.Bookmark
+			for _, b := range all {
+				if b.Match(e) {
+					want = append(want, b)
+				}
+			}
+			got, err := store.Query(e)
+			if err != nil {
+				t.Fatalf("Store.Query() error = %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Store.Query() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("query should match ids", func(t *testing.T) {
+		got, err := store.Query(&query.Or{Left: &query.IDs{IDs: []string{"3", "1"}}, Right: &query.IDs{}})
+		if err != nil {
+			t.Fatalf("Store.Query() error = %v", err)
+		}
+		if diff := cmp.Diff([]*bookmark.Bookmark{all[0], all[2]}, got); diff != "" {
+			t.Errorf("Store.Query() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("query should match more ids than sqlite has parameters", func(t *testing.T) {
+		ids := []string{"2"}
+		for i := range 100_000 {
+			ids = append(ids, fmt.Sprintf("missing-%d", i))
+		}
+		got, err := store.Query(&query.IDs{IDs: ids})
+		if err != nil {
+			t.Fatalf("Store.Query() error = %v", err)
+		}
+		if diff := cmp.Diff([]*bookmark.Bookmark{all[1]}, got); diff != "" {
+			t.Errorf("Store.Query() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestStore_SaveSearch(t *testing.T) {
+	t.Run("saved searches should be listed by name", func(t *testing.T) {
+		store := newStore(t)
+		want := []*bookmark.SavedSearch{
+			{Name: "a", Query: "tag:go", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "b", Query: "gthb", Mode: query.ModeFuzzy},
+		}
+		for _, s := range []*bookmark.SavedSearch{want[1], {Name: "a", Query: "old"}, want[0]} {
+			if err := store.SaveSearch(s); err != nil {
+				t.Fatalf("Store.SaveSearch() error = %v", err)
+			}
+		}
+		got, err := store.Searches()
+		if err != nil {
+			t.Fatalf("Store.Searches() error = %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Searches() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("delete search should return ErrSearchNotFound for an unknown name", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SaveSearch(&bookmark.SavedSearch{Name: "a", Query: "go"}); err != nil {
+			t.Fatalf("Store.SaveSearch() error = %v", err)
+		}
+		if err := store.DeleteSearch("a"); err != nil {
+			t.Fatalf("Store.DeleteSearch() error = %v", err)
+		}
+		if err := store.DeleteSearch("a"); !errors.Is(err, bookmark.ErrSearchNotFound) {
+			t.Errorf("Store.DeleteSearch() error = %v, want %v", err, bookmark.ErrSearchNotFound)
+		}
+	})
+}
+
+func TestStore_AddCollection(t *testing.T) {
+	t.Run("collections should be listed with collections before the ones inside them", func(t *testing.T) {
+		store := newStore(t)
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, path := range []string{"dev-tools", "dev/go", "dev", "dev/go"} {
+			if err := store.AddCollection(&bookmark.Collection{Path: path, CreatedAt: created}); err != nil {
+				t.Fatalf("Store.AddCollection() error = %v", err)
+			}
+		}
+		got, err := store.Collections()
+		if err != nil {
+			t.Fatalf("Store.Collections() error = %v", err)
+		}
+		want := []*bookmark.Collection{
+			{Path: "dev", CreatedAt: created},
+			{Path: "dev/go", CreatedAt: created},
+			{Path: "dev-tools", CreatedAt: created},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Store.Collections() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("delete collection should return ErrCollectionNotFound for an unknown path", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.AddCollection(&bookmark.Collection{Path: "dev"}); err != nil {
+			t.Fatalf("Store.AddCollection() error = %v", err)
+		}
+		if err := store.DeleteCollection("dev"); err != nil {
+			t.Fatalf("Store.DeleteCollection() error = %v", err)
+		}
+		if err := store.DeleteCollection("dev"); !errors.Is(err, bookmark.ErrCollectionNotFound) {
+			t.Errorf("Store.DeleteCollection() error = %v, want %v", err, bookmark.ErrCollectionNotFound)
+		}
+	})
+}
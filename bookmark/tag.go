@@ -0,0 +1,60 @@
+package bookmark
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagCount is a tag together with the number of bookmarks that have it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// NormalizeTags lowercases and trims the given tags, drops empty ones and
+// duplicates and returns them sorted. Tags are single words in search
+// queries, so whitespace inside a tag is replaced by dashes.
+func NormalizeTags(tags []string) []string {
+	var result []string
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(strings.ToLower(t)), "-")
+		if t == "" || slices.Contains(result, t) {
+			continue
+		}
+		result = append(result, t)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// HasTags reports whether the bookmark has all the given tags.
+func (b *Bookmark) HasTags(tags ...string) bool {
+	for _, t := range NormalizeTags(tags) {
+		if !slices.Contains(b.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// countTags counts how often every tag is used. The result is sorted by
+// count, most used first, and then by tag.
+func countTags(bookmarks []*Bookmark) []TagCount {
+	counts := map[string]int{}
+	for _, b := range bookmarks {
+		for _, t := range b.Tags {
+			counts[t]++
+		}
+	}
+	result := make([]TagCount, 0, len(counts))
+	for t, c := range counts {
+		result = append(result, TagCount{Tag: t, Count: c})
+	}
+	slices.SortFunc(result, func(a, b TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return result
+}
@@ -0,0 +1,51 @@
+package bookmark_test
+
+import (
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "nil", tags: nil, want: nil},
+		{name: "lowercase and trim", tags: []string{" Go ", "WEB"}, want: []string{"go", "web"}},
+		{name: "drop empty and duplicates", tags: []string{"go", "", "Go", " "}, want: []string{"go"}},
+		{name: "whitespace inside", tags: []string{"Bookmarks  bar"}, want: []string{"bookmarks-bar"}},
+		{name: "sorted", tags: []string{"b", "c", "a"}, want: []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, bookmark.NormalizeTags(tt.tags)); diff != "" {
+				t.Errorf("NormalizeTags() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBookmark_HasTags(t *testing.T) {
+	b := &bookmark.Bookmark{Tags: []string{"go", "web"}}
+	tests := []struct {
+		name string
+		tags []string
+		want bool
+	}{
+		{name: "no tags", tags: nil, want: true},
+		{name: "one tag", tags: []string{"go"}, want: true},
+		{name: "case insensitive", tags: []string{"Go"}, want: true},
+		{name: "all tags", tags: []string{"web", "go"}, want: true},
+		{name: "missing tag", tags: []string{"go", "rust"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.HasTags(tt.tags...); got != tt.want {
+				t.Errorf("Bookmark.HasTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/DWethmar/bookmarks/bookmark"
 	"github.com/spf13/cobra"
@@ -23,30 +22,66 @@ func runAddCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get name flag: %w", err)
 	}
+	tags, err := cmd.Flags().GetStringSlice("tag")
+	if err != nil {
+		return fmt.Errorf("failed to get tag flag: %w", err)
+	}
+	collection, err := cmd.Flags().GetString("collection")
+	if err != nil {
+		return fmt.Errorf("failed to get collection flag: %w", err)
+	}
+	offline, err := cmd.Flags().GetBool("offline")
+	if err != nil {
+		return fmt.Errorf("failed to get offline flag: %w", err)
+	}
+	archiveOnAdd, err := cmd.Flags().GetBool("archive")
+	if err != nil {
+		return fmt.Errorf("failed to get archive flag: %w", err)
+	}
+	allowDuplicate, err := cmd.Flags().GetBool("allow-duplicate")
+	if err != nil {
+		return fmt.Errorf("failed to get allow-duplicate flag: %w", err)
+	}
 	if len(args) == 0 {
 		return errors.New("no content provided")
 	}
 	content := args[0]
 	// create bookmark
 	b := &bookmark.Bookmark{
-		Title:     title,
-		Content:   content,
-		CreatedAt: time.Now(),
-	}
-	lib, err := setupBookmarks(loadLibraryOptions{
-		Verbose: cmd.Flag("verbose").Changed,
-		DBName:  appName,
-	})
+		Title:      title,
+		Content:    content,
+		Tags:       tags,
+		Collection: collection,
+	}
+	opts := libraryOptions(cmd)
+	opts.Offline = offline
+	opts.ArchiveOnAdd = archiveOnAdd
+	opts.AllowDuplicate = allowDuplicate
+	lib, err := setupBookmarks(opts)
 	if err != nil {
 		return err
 	}
+	defer lib.Close()
 	if err = lib.Add(cmd.Context(), b); err != nil {
+		var dup *bookmark.DuplicateError
+		if errors.As(err, &dup) {
+			return fmt.Errorf("%s is already bookmarked as %s (%s), use --allow-duplicate to add it anyway",
+				b.Content, dup.Existing.ID, dup.Existing.Title)
+		}
 		return fmt.Errorf("failed to add bookmark: %w", err)
 	}
+	if b.PendingMetadata {
+		fmt.Fprintf(cmd.ErrOrStderr(), "metadata of %s is pending, run %s refresh to fetch it\n", b.ID, appName)
+	}
 	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.Flags().StringP("title", "t", "", "title of the bookmark")
+	addCmd.Flags().StringSlice("tag", nil, "tag of the bookmark, can be repeated")
+	addCmd.Flags().StringP("collection", "c", "", "path of the collection to add the bookmark to, like dev/go")
+	addCmd.Flags().Bool("offline", false, "save the bookmark without fetching the page, refresh fetches it later")
+	addCmd.Flags().Bool("archive", false, "archive a snapshot of the page as a single html file")
+	addCmd.Flags().Bool("allow-duplicate", false, "add the bookmark even when its url is already bookmarked")
 }
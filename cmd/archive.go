@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/archive"
+	"github.com/spf13/cobra"
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive [id|title]...",
+	Short: "Archive snapshots of pages",
+	Long: `Archive a snapshot of the pages of bookmarks, so they can be read when the link is gone.
+Snapshots are stored by their hash in the archive directory of the config directory.`,
+	RunE: runArchiveCmd,
+}
+
+// runArchiveCmd represents the command to run when the archive command is specified
+func runArchiveCmd(cmd *cobra.Command, args []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return fmt.Errorf("failed to get all flag: %w", err)
+	}
+	format := archive.Format(cmd.Flag("format").Value.String())
+	if !slices.Contains(archive.FormatNames(), string(format)) {
+		return fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(archive.FormatNames(), ", "))
+	}
+	if all == (len(args) > 0) {
+		return errors.New("give the bookmarks to archive or --all")
+	}
+	opts := libraryOptions(cmd)
+	opts.ArchiveFormat = format
+	lib, err := setupBookmarks(opts)
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	var bookmarks []*bookmark.Bookmark
+	if all {
+		bookmarks, err = lib.List()
+	} else {
+		bookmarks, err = resolveBookmarks(lib, args)
+	}
+	if err != nil {
+		return err
+	}
+	var archived, failed int
+	for _, b := range bookmarks {
+		if !b.HasURL() {
+			continue
+		}
+		updated, aErr := lib.Archive(cmd.Context(), b.ID)
+		if aErr != nil {
+			failed++
+			fmt.Fprintf(cmd.ErrOrStderr(), "could not archive %s: %v\n", b.ID, aErr)
+			continue
+		}
+		archived++
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", b.ID, updated.Snapshot.Path)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "archived %d, failed %d\n", archived, failed)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.Flags().Bool("all", false, "archive the pages of all bookmarks")
+	archiveCmd.Flags().StringP("format", "f", string(archive.FormatSingleFile),
+		fmt.Sprintf("format of the snapshot, one of %s", strings.Join(archive.FormatNames(), ", ")))
+}
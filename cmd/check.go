@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check for broken links",
+	Long: `Check the links of all bookmarks and report the ones that are broken or redirect.
+The status, final url, time of the check and the number of consecutive failures are saved with every bookmark.`,
+	Args: cobra.NoArgs,
+	RunE: runCheckCmd,
+}
+
+// runCheckCmd represents the command to run when the check command is specified
+func runCheckCmd(cmd *cobra.Command, _ []string) error {
+	tags, err := cmd.Flags().GetStringSlice("tag")
+	if err != nil {
+		return fmt.Errorf("failed to get tag flag: %w", err)
+	}
+	opts := bookmark.CheckOptions{}
+	if opts.Concurrency, err = cmd.Flags().GetInt("concurrency"); err != nil {
+		return fmt.Errorf("failed to get concurrency flag: %w", err)
+	}
+	if opts.FixRedirects, err = cmd.Flags().GetBool("fix-redirects"); err != nil {
+		return fmt.Errorf("failed to get fix-redirects flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	bookmarks, err := findBookmarks(lib, filterOptions{Tags: tags})
+	if err != nil {
+		return err
+	}
+	bookmarks = slices.DeleteFunc(bookmarks, func(b *bookmark.Bookmark) bool { return !b.HasURL() })
+	opts.Progress = progressBar(cmd.ErrOrStderr())
+	results := lib.Check(cmd.Context(), bookmarks, opts)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "could not check %s: %v\n", r.Bookmark.ID, r.Err)
+		}
+	}
+	printCheckReport(cmd.OutOrStdout(), results)
+	return nil
+}
+
+// printCheckReport prints the broken and redirected links of a check.
+func printCheckReport(w io.Writer, results []bookmark.CheckResult) {
+	var broken, redirected []bookmark.CheckResult
+	var fixed int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+		case r.Updated.Health.Broken():
+			broken = append(broken, r)
+		case r.Fixed() || r.Updated.Redirected():
+			redirected = append(redirected, r)
+		}
+	}
+	if len(broken) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(tw, "Broken\tStatus\tFailures\tURL")
+		for _, r := range broken {
+			h := r.Updated.Health
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", r.Updated.ID, statusText(h), h.Failures, r.Updated.Content)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+	if len(redirected) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(tw, "Redirected\tRedirect\tURL\tTarget")
+		for _, r := range redirected {
+			kind := "temporary"
+			switch {
+			case r.Fixed():
+				fixed++
+				kind = "fixed"
+			case r.Updated.Health.PermanentRedirect:
+				kind = "permanent"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Updated.ID, kind, r.Bookmark.Content, r.Updated.Health.FinalURL)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "checked %d, broken %d, redirected %d, fixed %d\n", len(results), len(broken), len(redirected), fixed)
+}
+
+// statusText returns the HTTP status of a check, or why it failed.
+func statusText(h bookmark.Health) string {
+	if h.Error != "" {
+		return h.Error
+	}
+	return fmt.Sprintf("%d %s", h.StatusCode, http.StatusText(h.StatusCode))
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().StringSlice("tag", nil, "only check bookmarks with this tag, can be repeated")
+	checkCmd.Flags().IntP("concurrency", "c", bookmark.DefaultCheckConcurrency, "number of links to check at once")
+	checkCmd.Flags().Bool("fix-redirects", false, "replace links that redirect permanently by their target")
+}
@@ -0,0 +1,47 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/cmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintCheckReport(t *testing.T) {
+	result := func(id, content string, h bookmark.Health, fixed bool) bookmark.CheckResult {
+		updated := &bookmark.Bookmark{ID: id, Content: content, Health: h}
+		if fixed {
+			updated = &bookmark.Bookmark{ID: id, Content: h.FinalURL, Health: h}
+		}
+		return bookmark.CheckResult{Bookmark: &bookmark.Bookmark{ID: id, Content: content}, Updated: updated}
+	}
+	results := []bookmark.CheckResult{
+		result("ok", "https://ok.test", bookmark.Health{StatusCode: 200, FinalURL: "https://ok.test"}, false),
+		result("gone", "https://gone.test", bookmark.Health{
+			StatusCode: 404, FinalURL: "https://gone.test", Failures: 2,
+		}, false),
+		result("moved", "https://old.test", bookmark.Health{
+			StatusCode: 200, FinalURL: "https://new.test", PermanentRedirect: true,
+		}, false),
+		result("fixed", "https://a.test", bookmark.Health{
+			StatusCode: 200, FinalURL: "https://b.test", PermanentRedirect: true,
+		}, true),
+		{Bookmark: &bookmark.Bookmark{ID: "note", Content: "a note"}, Err: bookmark.ErrNotFound},
+	}
+	var b strings.Builder
+	cmd.PrintCheckReport(&b, results)
+	want := `Broken Status        Failures URL
+gone   404 Not Found 2        https://gone.test
+
+Redirected Redirect  URL              Target
+moved      permanent https://old.test https://new.test
+fixed      fixed     https://a.test   https://b.test
+
+checked 5, broken 1, redirected 2, fixed 1
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printCheckReport() mismatch (-want +got):\n%s", diff)
+	}
+}
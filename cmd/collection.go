@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/spf13/cobra"
+)
+
+// idStyle dims the IDs of bookmarks in trees.
+//
+//nolint:gochecknoglobals // styles are static
+var idStyle = lipgloss.NewStyle().Faint(true)
+
+// mkdirCmd represents the mkdir command
+var mkdirCmd = &cobra.Command{
+	Use:   "mkdir <collection>...",
+	Short: "Create collections",
+	Long: `Create one or more collections. Collections are nested by separating their names with a slash,
+the collections around a new collection are created as well.`,
+	Example: "  bookmarks mkdir dev/go reading",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runMkdirCmd,
+}
+
+// rmdirCmd represents the rmdir command
+var rmdirCmd = &cobra.Command{
+	Use:   "rmdir <collection>...",
+	Short: "Remove empty collections",
+	Long:  "Remove one or more collections that hold no bookmarks or other collections",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runRmdirCmd,
+}
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv <id|title|collection>... <collection>",
+	Short: "Move bookmarks and collections",
+	Long: `Move bookmarks and collections to a collection. A collection that is moved to an existing
+collection is moved into it, otherwise it is renamed. Moving to / moves out of all collections.
+
+Arguments are bookmarks by id or title, or collections when no bookmark has that title.
+A trailing slash always means a collection.`,
+	Example: `  bookmarks mv 01JQ8Z4K6N7W3X2Y1V0T9S8R7Q dev/go
+  bookmarks mv go/ dev
+  bookmarks mv dev/go dev/golang`,
+	Args: cobra.MinimumNArgs(2), //nolint:mnd // at least one source and a destination
+	RunE: runMvCmd,
+}
+
+// runMkdirCmd represents the command to run when the mkdir command is specified
+func runMkdirCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	for _, path := range args {
+		if _, err = lib.MakeCollection(path); err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// runRmdirCmd represents the command to run when the rmdir command is specified
+func runRmdirCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	for _, path := range args {
+		if err = lib.RemoveCollection(path); err != nil {
+			return fmt.Errorf("failed to remove collection %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// runMvCmd represents the command to run when the mv command is specified
+func runMvCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	to := args[len(args)-1]
+	for _, ref := range args[:len(args)-1] {
+		bookmarks, collection, rErr := resolveMoveSource(lib, ref)
+		if rErr != nil {
+			return rErr
+		}
+		if collection != "" {
+			if _, err = lib.MoveCollection(collection, to); err != nil {
+				return fmt.Errorf("failed to move collection %s: %w", collection, err)
+			}
+			continue
+		}
+		for _, b := range bookmarks {
+			if _, err = lib.Move(b.ID, to); err != nil {
+				return fmt.Errorf("failed to move bookmark %s: %w", b.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveMoveSource resolves what mv moves: the bookmarks with the given
+// ID or title, or else the collection with the given path.
+func resolveMoveSource(lib *bookmark.Library, ref string) ([]*bookmark.Bookmark, string, error) {
+	if !strings.HasSuffix(ref, bookmark.CollectionSeparator) {
+		bookmarks, err := lib.Resolve(ref)
+		if err == nil {
+			return bookmarks, "", nil
+		}
+		if !errors.Is(err, bookmark.ErrNotFound) {
+			return nil, "", fmt.Errorf("failed to find bookmark %q: %w", ref, err)
+		}
+	}
+	ok, err := lib.HasCollection(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list collections: %w", err)
+	}
+	if !ok || bookmark.NormalizeCollection(ref) == "" {
+		return nil, "", fmt.Errorf("no bookmark or collection %q", ref)
+	}
+	return nil, bookmark.NormalizeCollection(ref), nil
+}
+
+// printTree prints the collections within root as a tree, with the
+// bookmarks in them below their collections.
+func printTree(w io.Writer, root string, collections []string, bookmarks []*bookmark.Bookmark) error {
+	nodes := map[string]*tree.Tree{root: tree.New()}
+	if root != "" {
+		nodes[root].Root(root + bookmark.CollectionSeparator)
+	}
+	// collections are sorted, so parents come before their children
+	for _, c := range collections {
+		names := bookmark.SplitCollection(c)
+		parent, ok := nodes[bookmark.JoinCollection(names[:len(names)-1]...)]
+		if c == root || !ok {
+			continue
+		}
+		nodes[c] = tree.Root(names[len(names)-1] + bookmark.CollectionSeparator)
+		parent.Child(nodes[c])
+	}
+	for _, b := range bookmarks {
+		if n, ok := nodes[b.Collection]; ok {
+			n.Child(b.Title + "  " + idStyle.Render(b.ID))
+		}
+	}
+	_, err := fmt.Fprintln(w, nodes[root].String())
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(mkdirCmd)
+	rootCmd.AddCommand(rmdirCmd)
+	rootCmd.AddCommand(mvCmd)
+}
@@ -0,0 +1,52 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/cmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintTree(t *testing.T) {
+	collections := []string{"dev", "dev/go", "reading"}
+	bookmarks := []*bookmark.Bookmark{
+		{ID: "1", Title: "Go blog", Collection: "dev/go"},
+		{ID: "2", Title: "Note"},
+		{ID: "3", Title: "Later", Collection: "reading"},
+	}
+	tests := []struct {
+		name string
+		root string
+		want string
+	}{
+		{
+			name: "tree should show all collections below the root",
+			want: "├── dev/\n" +
+				"│   └── go/\n" +
+				"│       └── Go blog  1\n" +
+				"├── reading/\n" +
+				"│   └── Later  3\n" +
+				"└── Note  2\n",
+		},
+		{
+			name: "tree should start at the given collection",
+			root: "dev",
+			want: "dev/\n" +
+				"└── go/\n" +
+				"    └── Go blog  1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := cmd.PrintTree(&b, tt.root, collections, bookmarks); err != nil {
+				t.Fatalf("printTree() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("printTree() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/archive"
 	"github.com/DWethmar/bookmarks/bookmark/json"
+	"github.com/DWethmar/bookmarks/bookmark/search"
+	"github.com/DWethmar/bookmarks/bookmark/sqlite"
+	"github.com/spf13/cobra"
 )
 
 // ConfigDir returns the appropriate configuration directory for the given OS.
@@ -47,10 +54,90 @@ func Logger(verbose bool) *slog.Logger {
 	}))
 }
 
+// archiveDir is the directory in the workdir where snapshots are stored.
+const archiveDir = "archive"
+
+// indexExt is the extension of the search index file, which is named after
+// the database and the store.
+const indexExt = ".index"
+
+// Supported store drivers.
+const (
+	storeJSON   = "json"
+	storeSQLite = "sqlite"
+)
+
 // loadLibraryOptions are the options for loading a library.
 type loadLibraryOptions struct {
 	Verbose bool
 	DBName  string
+	// Store is the store driver, either json or sqlite.
+	Store string
+	// LockTimeout is how long the json store waits for other processes.
+	LockTimeout time.Duration
+	// FetchPolicy limits how pages are fetched.
+	FetchPolicy bookmark.FetchPolicy
+	// Offline saves bookmarks without fetching their metadata.
+	Offline bool
+	// ArchiveFormat is the format of snapshots, single when empty.
+	ArchiveFormat archive.Format
+	// ArchiveOnAdd archives the pages of bookmarks that are added.
+	ArchiveOnAdd bool
+	// AllowDuplicate adds bookmarks of URLs that are already bookmarked.
+	AllowDuplicate bool
+	// Canonicalizer finds the duplicates of bookmarks.
+	Canonicalizer bookmark.Canonicalizer
+}
+
+// libraryOptions returns the options for loading a library from the flags
+// of the given command.
+func libraryOptions(cmd *cobra.Command) loadLibraryOptions {
+	// the flags are validated by cobra when they are parsed
+	lockTimeout, _ := cmd.Flags().GetDuration("lock-timeout")
+	policy := bookmark.DefaultFetchPolicy()
+	policy.Timeout, _ = cmd.Flags().GetDuration("fetch-timeout")
+	policy.MaxBodyBytes, _ = cmd.Flags().GetInt64("max-page-size")
+	policy.DenyPrivateNetworks, _ = cmd.Flags().GetBool("deny-private-networks")
+	return loadLibraryOptions{
+		Verbose:       cmd.Flag("verbose").Changed,
+		DBName:        appName,
+		Store:         cmd.Flag("store").Value.String(),
+		LockTimeout:   lockTimeout,
+		FetchPolicy:   policy,
+		Canonicalizer: canonicalFlags(cmd),
+	}
+}
+
+// addCanonicalFlags adds the flags that configure how duplicate urls are
+// found to the command and its subcommands.
+func addCanonicalFlags(cmd *cobra.Command) {
+	c := bookmark.DefaultCanonicalizer()
+	cmd.PersistentFlags().StringSlice("canonical-remove-param", c.RemoveParams,
+		"query parameters that urls of the same page differ in, a trailing * matches every parameter it starts")
+	cmd.PersistentFlags().Bool("canonical-keep-param-order", false,
+		"urls whose query parameters are in a different order are different pages")
+	cmd.PersistentFlags().Bool("canonical-keep-trailing-slash", false,
+		"urls with and without a trailing slash are different pages")
+	cmd.PersistentFlags().Bool("canonical-remove-fragment", false,
+		"urls that only differ in their fragment are the same page")
+	cmd.PersistentFlags().Bool("canonical-keep-scheme", false,
+		"the http and https urls of a page are different pages")
+}
+
+// canonicalFlags returns the canonicalizer configured by the flags of the
+// command.
+func canonicalFlags(cmd *cobra.Command) bookmark.Canonicalizer {
+	// the flags are validated by cobra when they are parsed
+	var c bookmark.Canonicalizer
+	c.RemoveParams, _ = cmd.Flags().GetStringSlice("canonical-remove-param")
+	keepOrder, _ := cmd.Flags().GetBool("canonical-keep-param-order")
+	keepSlash, _ := cmd.Flags().GetBool("canonical-keep-trailing-slash")
+	c.RemoveFragment, _ = cmd.Flags().GetBool("canonical-remove-fragment")
+	keepScheme, _ := cmd.Flags().GetBool("canonical-keep-scheme")
+	c.SortParams = !keepOrder
+	c.RemoveTrailingSlash = !keepSlash
+	c.IgnoreScheme = !keepScheme
+	return c
 }
 
 // setupBookmarks loads a library.
@@ -67,7 +154,77 @@ func setupBookmarks(o loadLibraryOptions) (*bookmark.Library, error) {
 		slog.String("appName", appName),
 		slog.String("goos", runtime.GOOS),
 		slog.String("dbName", o.DBName),
+		slog.String("store", o.Store),
 	)
-	store := json.NewStore(path.Join(workDir, fmt.Sprintf("%s.json", o.DBName)))
-	return bookmark.NewLibrary(logger, store), nil
+	store, err := openStore(o, workDir)
+	if err != nil {
+		return nil, err
+	}
+	format := o.ArchiveFormat
+	if format == "" {
+		format = archive.FormatSingleFile
+	}
+	client := bookmark.NewHTTPClient(o.FetchPolicy)
+	// bookmarks are saved with their metadata pending when it cannot be
+	// fetched, so adding works without a network
+	opts := []bookmark.Option{
+		bookmark.WithLenientFetch(),
+		bookmark.WithHTTPClient(client),
+		bookmark.WithArchiver(archive.New(filepath.Join(workDir, archiveDir), client, format)),
+		bookmark.WithCanonicalizer(o.Canonicalizer),
+	}
+	if o.ArchiveOnAdd {
+		opts = append(opts, bookmark.WithArchiveOnAdd())
+	}
+	if o.Offline {
+		opts = append(opts, bookmark.WithOffline())
+	}
+	if o.AllowDuplicate {
+		opts = append(opts, bookmark.WithAllowDuplicates())
+	}
+	storeName := o.Store
+	if storeName == "" {
+		storeName = storeJSON
+	}
+	idx, err := search.Open(filepath.Join(workDir, o.DBName+"."+storeName+indexExt))
+	if err != nil {
+		return nil, errors.Join(err, closeStore(store))
+	}
+	opts = append(opts, bookmark.WithIndex(idx))
+	lib := bookmark.NewLibrary(logger, store, opts...)
+	// a new index, or one that misses changes of other processes, is built
+	// from the store
+	if idx.Len() == 0 || lib.IndexOutdated() {
+		if _, err = lib.Reindex(); err != nil {
+			return nil, errors.Join(fmt.Errorf("could not build search index: %w", err), lib.Close())
+		}
+	}
+	return lib, nil
+}
+
+// closeStore closes a store that needs closing.
+func closeStore(store bookmark.Store) error {
+	if c, ok := store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// openStore opens the store with the configured driver in the workdir.
+func openStore(o loadLibraryOptions, workDir string) (bookmark.Store, error) {
+	switch o.Store {
+	case storeJSON, "":
+		return json.NewStore(
+			path.Join(workDir, fmt.Sprintf("%s.json", o.DBName)),
+			json.WithLockTimeout(o.LockTimeout),
+		), nil
+	case storeSQLite:
+		store, err := sqlite.NewStore(path.Join(workDir, fmt.Sprintf("%s.db", o.DBName)))
+		if err != nil {
+			return nil, fmt.Errorf("could not open sqlite store: %w", err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown store %q, use %s or %s", o.Store, storeJSON, storeSQLite)
+	}
 }
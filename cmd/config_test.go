@@ -5,7 +5,10 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/DWethmar/bookmarks/bookmark"
 	"github.com/DWethmar/bookmarks/cmd"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
 )
 
 func TestConfigDir(t *testing.T) {
@@ -77,3 +80,49 @@ func TestConfigDir(t *testing.T) {
 		}
 	})
 }
+
+func TestCanonicalFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bookmark.Canonicalizer
+	}{
+		{
+			name: "no flags",
+			want: bookmark.DefaultCanonicalizer(),
+		},
+		{
+			name: "all flags",
+			args: []string{
+				"--canonical-remove-param", "ref,utm_*",
+				"--canonical-keep-param-order",
+				"--canonical-keep-trailing-slash",
+				"--canonical-remove-fragment",
+				"--canonical-keep-scheme",
+			},
+			want: bookmark.Canonicalizer{RemoveParams: []string{"ref", "utm_*"}, RemoveFragment: true},
+		},
+		{
+			name: "no params",
+			args: []string{"--canonical-remove-param", ""},
+			want: bookmark.Canonicalizer{
+				RemoveParams:        []string{},
+				SortParams:          true,
+				RemoveTrailingSlash: true,
+				IgnoreScheme:        true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cobra.Command{}
+			cmd.AddCanonicalFlags(c)
+			if err := c.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, cmd.CanonicalFlags(c)); diff != "" {
+				t.Errorf("canonicalFlags() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
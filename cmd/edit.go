@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultEditor = "vi"
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <id|title>",
+	Short: "Edit a bookmark",
+	Long:  "Edit a bookmark as a YAML document in $EDITOR and save the changes",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEditCmd,
+}
+
+// editDocument is the part of a bookmark that can be edited.
+type editDocument struct {
+	Title      string   `yaml:"title"`
+	Content    string   `yaml:"content"`
+	Tags       []string `yaml:"tags"`
+	Collection string   `yaml:"collection"`
+}
+
+// runEditCmd represents the command to run when the edit command is specified
+func runEditCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	b, err := resolveBookmark(lib, args[0])
+	if err != nil {
+		return err
+	}
+	doc, err := editInEditor(b)
+	if err != nil {
+		return err
+	}
+	p := &bookmark.Patch{}
+	changed := false
+	if doc.Title != b.Title {
+		p.Title = &doc.Title
+		changed = true
+	}
+	if doc.Content != b.Content {
+		p.Content = &doc.Content
+		changed = true
+	}
+	if tags := bookmark.NormalizeTags(doc.Tags); !slices.Equal(tags, b.Tags) {
+		p.Tags = &tags
+		changed = true
+	}
+	if collection := bookmark.NormalizeCollection(doc.Collection); collection != b.Collection {
+		p.Collection = &collection
+		changed = true
+	}
+	if !changed {
+		fmt.Fprintln(cmd.OutOrStdout(), "no changes")
+		return nil
+	}
+	if _, err = lib.Update(b.ID, p); err != nil {
+		return fmt.Errorf("failed to update bookmark %s: %w", b.ID, err)
+	}
+	return nil
+}
+
+// editInEditor writes the bookmark to a temporary file, opens it in the
+// editor of the user and reads the result back.
+func editInEditor(b *bookmark.Bookmark) (*editDocument, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# id: %s\n# created at: %s\n", b.ID, b.CreatedAt.Format(time.DateTime))
+	enc := yaml.NewEncoder(&buf)
+	doc := &editDocument{Title: b.Title, Content: b.Content, Tags: b.Tags, Collection: b.Collection}
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode bookmark: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode bookmark: %w", err)
+	}
+	f, err := os.CreateTemp("", "bookmark-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	// the file is kept when the edits cannot be parsed, so they are not lost
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err = runEditor(f.Name()); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temp file: %w", err)
+	}
+	doc = &editDocument{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		keep = true
+		return nil, fmt.Errorf("failed to parse edited bookmark, the edits are kept in %s: %w", f.Name(), err)
+	}
+	return doc, nil
+}
+
+// runEditor opens the file in $VISUAL or $EDITOR, falling back to vi.
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	// the editor may contain arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	if len(parts) == 0 {
+		return errors.New("no editor configured")
+	}
+	c := exec.Command(parts[0], append(parts[1:], file)...) //nolint:gosec // the editor is chosen by the user
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
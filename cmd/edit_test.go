@@ -0,0 +1,87 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/cmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditInEditor(t *testing.T) {
+	// editor writes the document to the file it edits
+	editor := func(t *testing.T, document string) {
+		t.Helper()
+		dir := t.TempDir()
+		script := filepath.Join(dir, "editor")
+		if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$1\" <<'EOF'\n"+document+"EOF\n"), 0o700); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+		t.Setenv("VISUAL", script)
+		t.Setenv("TMPDIR", dir)
+	}
+	b := &bookmark.Bookmark{ID: "1", Title: "Go", Content: "https://go.dev", Tags: []string{"go"}}
+
+	t.Run("edit should return the edited document", func(t *testing.T) {
+		editor(t, "title: Go blog\ncontent: https://go.dev/blog\ntags: [go, blog]\ncollection: dev/go\n")
+		doc, err := cmd.EditInEditor(b)
+		if err != nil {
+			t.Fatalf("editInEditor() error = %v", err)
+		}
+		got := []string{doc.Title, doc.Content, strings.Join(doc.Tags, ","), doc.Collection}
+		if diff := cmp.Diff([]string{"Go blog", "https://go.dev/blog", "go,blog", "dev/go"}, got); diff != "" {
+			t.Errorf("editInEditor() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("edit should show the collection", func(t *testing.T) {
+		dir := t.TempDir()
+		shown := filepath.Join(dir, "shown.yaml")
+		script := filepath.Join(dir, "editor")
+		if err := os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" "+shown+"\n"), 0o700); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+		t.Setenv("VISUAL", script)
+		b := &bookmark.Bookmark{ID: "1", Title: "Go", Content: "https://go.dev", Collection: "dev/go"}
+		doc, err := cmd.EditInEditor(b)
+		if err != nil {
+			t.Fatalf("editInEditor() error = %v", err)
+		}
+		if doc.Collection != "dev/go" {
+			t.Errorf("Collection = %q, want dev/go", doc.Collection)
+		}
+		data, err := os.ReadFile(shown)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if !strings.Contains(string(data), "collection: dev/go\n") {
+			t.Errorf("editor was shown %q, want the collection", data)
+		}
+	})
+
+	t.Run("edit should keep the file when it cannot be parsed", func(t *testing.T) {
+		const document = "title: [Go blog\n"
+		editor(t, document)
+		_, err := cmd.EditInEditor(b)
+		if err == nil {
+			t.Fatal("editInEditor() should fail")
+		}
+		files, gErr := filepath.Glob(filepath.Join(os.Getenv("TMPDIR"), "bookmark-*.yaml"))
+		if gErr != nil || len(files) != 1 {
+			t.Fatalf("kept files = %v, %v, want one file", files, gErr)
+		}
+		if !strings.Contains(err.Error(), files[0]) {
+			t.Errorf("editInEditor() error = %v, want the path of the kept file", err)
+		}
+		data, rErr := os.ReadFile(files[0])
+		if rErr != nil {
+			t.Fatalf("os.ReadFile() error = %v", rErr)
+		}
+		if string(data) != document {
+			t.Errorf("kept file = %q, want %q", data, document)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark/export"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export bookmarks",
+	Long: `Export bookmarks as a Netscape bookmark file that browsers can import, as JSON, CSV or as a Markdown list.
+The same filters as ls can be used to export a part of the bookmarks.`,
+	Args: cobra.NoArgs,
+	RunE: runExportCmd,
+}
+
+// runExportCmd represents the command to run when the export command is specified
+func runExportCmd(cmd *cobra.Command, _ []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to get format flag: %w", err)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("failed to get output flag: %w", err)
+	}
+	writer, ok := export.Formats()[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(export.FormatNames(), ", "))
+	}
+	filter, err := filterFlags(cmd)
+	if err != nil {
+		return err
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	bookmarks, err := findBookmarks(lib, filter)
+	if err != nil {
+		return err
+	}
+	if output == "" || output == "-" {
+		if err = writer.Write(cmd.OutOrStdout(), bookmarks); err != nil {
+			return fmt.Errorf("failed to export bookmarks: %w", err)
+		}
+		return nil
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err = writer.Write(f, bookmarks); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to export bookmarks: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("format", "f", export.FormatHTML,
+		"export format, one of "+strings.Join(export.FormatNames(), ", "))
+	exportCmd.Flags().StringP("output", "o", "", "file to write to, stdout if empty")
+	addFilterFlags(exportCmd)
+}
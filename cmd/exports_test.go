@@ -0,0 +1,17 @@
+package cmd
+
+// Exported for the tests in package cmd_test.
+var (
+	PrintBookmarks    = printBookmarks
+	PrintCheckReport  = printCheckReport
+	PrintTree         = printTree
+	ParseAge          = parseAge
+	OpenTarget        = openTarget
+	EditInEditor      = editInEditor
+	AddCanonicalFlags = addCanonicalFlags
+	CanonicalFlags    = canonicalFlags
+	RootCmd           = rootCmd
+)
+
+// OutputOptions is exported for the tests in package cmd_test.
+type OutputOptions = outputOptions
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/spf13/cobra"
+)
+
+// filterOptions select the bookmarks that commands work on.
+type filterOptions struct {
+	Query  string
+	Search bookmark.SearchOptions
+	Tags   []string
+	// Collection is the path of the collection the bookmarks must be in.
+	Collection string
+}
+
+// findResults returns the results of the search query, or all bookmarks
+// when the query is empty, that have all the given tags and are in the
+// collection.
+func findResults(lib *bookmark.Library, f filterOptions) ([]bookmark.SearchResult, error) {
+	var results []bookmark.SearchResult
+	if f.Query != "" {
+		var err error
+		if results, err = lib.Search(f.Query, f.Search); err != nil {
+			return nil, fmt.Errorf("failed to search bookmarks: %w", err)
+		}
+	} else {
+		bookmarks, err := lib.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bookmarks: %w", err)
+		}
+		for _, b := range bookmarks {
+			results = append(results, bookmark.SearchResult{Bookmark: b})
+		}
+	}
+	return slices.DeleteFunc(results, func(r bookmark.SearchResult) bool {
+		return !r.Bookmark.HasTags(f.Tags...) || !r.Bookmark.InCollection(f.Collection)
+	}), nil
+}
+
+// findBookmarks returns the bookmarks of findResults.
+func findBookmarks(lib *bookmark.Library, f filterOptions) ([]*bookmark.Bookmark, error) {
+	results, err := findResults(lib, f)
+	if err != nil {
+		return nil, err
+	}
+	return bookmark.Bookmarks(results), nil
+}
+
+// addFilterFlags adds the flags read by filterFlags to the command.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("search", "s", "", "only include bookmarks matching the search query, see search --help")
+	addModeFlag(cmd)
+	cmd.Flags().StringSlice("tag", nil, "only include bookmarks with this tag, can be repeated")
+}
+
+// filterFlags reads the search query, search mode and tags of the command.
+func filterFlags(cmd *cobra.Command) (filterOptions, error) {
+	var f filterOptions
+	var err error
+	if f.Query, err = cmd.Flags().GetString("search"); err != nil {
+		return filterOptions{}, fmt.Errorf("failed to get search flag: %w", err)
+	}
+	if f.Search, err = searchFlags(cmd); err != nil {
+		return filterOptions{}, err
+	}
+	if f.Tags, err = cmd.Flags().GetStringSlice("tag"); err != nil {
+		return filterOptions{}, fmt.Errorf("failed to get tag flag: %w", err)
+	}
+	return f, nil
+}
+
+// addModeFlag adds the search mode flag read by searchFlags to the command.
+func addModeFlag(cmd *cobra.Command) {
+	names := make([]string, 0, len(query.Modes))
+	for _, m := range query.Modes {
+		names = append(names, string(m))
+	}
+	cmd.Flags().String("mode", string(query.ModeSubstring),
+		"how words of the search query match, one of "+strings.Join(names, ", "))
+}
+
+// searchFlags reads the search options of the command.
+func searchFlags(cmd *cobra.Command) (bookmark.SearchOptions, error) {
+	name, err := cmd.Flags().GetString("mode")
+	if err != nil {
+		return bookmark.SearchOptions{}, fmt.Errorf("failed to get mode flag: %w", err)
+	}
+	mode, err := query.ParseMode(name)
+	if err != nil {
+		return bookmark.SearchOptions{}, err
+	}
+	return bookmark.SearchOptions{Mode: mode}, nil
+}
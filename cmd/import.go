@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import bookmarks from a browser",
+	Long: `Import bookmarks from a Netscape bookmark file, the bookmarks.html file that browsers export.
+Folders become collections, and with --folder-tags also tags. Bookmarks with a url that is already saved
+are skipped. Use - to read from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportCmd,
+}
+
+// runImportCmd represents the command to run when the import command is specified
+func runImportCmd(cmd *cobra.Command, args []string) error {
+	var r io.Reader = cmd.InOrStdin()
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open bookmark file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	folderTags, err := cmd.Flags().GetBool("folder-tags")
+	if err != nil {
+		return fmt.Errorf("failed to get folder-tags flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	summary, err := lib.ImportNetscape(r, bookmark.ImportOptions{FolderTags: folderTags})
+	if err != nil {
+		return fmt.Errorf("failed to import bookmarks: %w", err)
+	}
+	for _, e := range summary.Errors {
+		fmt.Fprintln(cmd.ErrOrStderr(), e)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "imported %d, skipped %d, failed %d\n",
+		summary.Imported, summary.Skipped, summary.Failed)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().Bool("folder-tags", false, "also tag bookmarks with the names of their folders")
+}
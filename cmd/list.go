@@ -5,9 +5,8 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
-	"time"
+	"io"
+	"slices"
 
 	"github.com/DWethmar/bookmarks/bookmark"
 	"github.com/spf13/cobra"
@@ -19,42 +18,61 @@ const (
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:   "ls",
+	Use:   "ls [collection]",
 	Short: "List all bookmarks",
-	Long:  `List all bookmarks that are saved in the bookmark manager`,
-	RunE:  runList,
+	Long: `List all bookmarks that are saved in the bookmark manager, or only the bookmarks in a collection
+and the collections inside it. --tree shows the collections as a tree with their bookmarks.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runList,
 }
 
 // runList represents the command to run when the list command is specified
-func runList(cmd *cobra.Command, _ []string) error {
-	lib, err := setupBookmarks(loadLibraryOptions{
-		Verbose: cmd.Flag("verbose").Changed,
-		DBName:  appName,
-	})
+func runList(cmd *cobra.Command, args []string) error {
+	filter, err := filterFlags(cmd)
 	if err != nil {
 		return err
 	}
-	bookmarks, err := lib.List()
+	output, err := outputFlags(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to list bookmarks: %w", err)
+		return err
+	}
+	showTree, err := cmd.Flags().GetBool("tree")
+	if err != nil {
+		return fmt.Errorf("failed to get tree flag: %w", err)
+	}
+	if len(args) > 0 {
+		filter.Collection = bookmark.NormalizeCollection(args[0])
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	collections, err := lib.Collections()
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	if filter.Collection != "" && !slices.Contains(collections, filter.Collection) {
+		return fmt.Errorf("%w: %s", bookmark.ErrCollectionNotFound, filter.Collection)
+	}
+	results, err := findResults(lib, filter)
+	if err != nil {
+		return err
 	}
-	if len(bookmarks) == 0 {
-		return nil
+	if showTree {
+		return printTree(cmd.OutOrStdout(), filter.Collection, collections, bookmark.Bookmarks(results))
 	}
-	table(bookmarks)
-	return nil
+	return printSearchResults(cmd.OutOrStdout(), results, output)
 }
 
-// table prints a table of bookmarks to the console
-func table(bookmarks []*bookmark.Bookmark) {
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
-	fmt.Fprintln(tw, "Title\tContent\tCreated At")
-	for _, b := range bookmarks {
-		fmt.Fprintf(tw, "%s\t%s\t%s\n", b.Title, b.Content, b.CreatedAt.Format(time.DateTime))
-	}
-	tw.Flush()
+// table prints a table of bookmarks to w
+func table(w io.Writer, bookmarks []*bookmark.Bookmark) {
+	_ = printBookmarks(w, bookmarks, outputOptions{Format: outputTable})
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	addFilterFlags(listCmd)
+	addOutputFlags(listCmd)
+	listCmd.Flags().Bool("tree", false, "show the collections as a tree with their bookmarks")
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/spf13/cobra"
+)
+
+// openCmd represents the open command
+var openCmd = &cobra.Command{
+	Use:   "open <id|title>",
+	Short: "Open a bookmark in the browser",
+	Long:  "Open the url of a bookmark, or with --archived its latest snapshot, in the default browser",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runOpenCmd,
+}
+
+// runOpenCmd represents the command to run when the open command is specified
+func runOpenCmd(cmd *cobra.Command, args []string) error {
+	archived, err := cmd.Flags().GetBool("archived")
+	if err != nil {
+		return fmt.Errorf("failed to get archived flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	b, err := resolveBookmark(lib, args[0])
+	if err != nil {
+		return err
+	}
+	target, err := openTarget(b, archived, filepath.Join(ConfigDir(runtime.GOOS, appName), archiveDir))
+	if err != nil {
+		return err
+	}
+	return openBrowser(target)
+}
+
+// openTarget returns what to open for a bookmark: its url, or its snapshot
+// when archived is set. Anything else, like a note with a path or a command,
+// is refused because the default application of the OS would open or run it.
+func openTarget(b *bookmark.Bookmark, archived bool, archiveDir string) (string, error) {
+	if !archived {
+		if !b.HasURL() {
+			return "", fmt.Errorf("bookmark %s is not a url", b.ID)
+		}
+		return b.Content, nil
+	}
+	if b.Snapshot.Path == "" {
+		return "", fmt.Errorf("bookmark %s has no snapshot, archive it with %s archive %s", b.ID, appName, b.ID)
+	}
+	rel, err := filepath.Rel(archiveDir, b.Snapshot.Path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("snapshot of bookmark %s is not in the archive directory %s", b.ID, archiveDir)
+	}
+	return b.Snapshot.Path, nil
+}
+
+// openBrowser opens a URL or file with the default application of the OS.
+func openBrowser(target string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	case "darwin":
+		c = exec.Command("open", target)
+	default:
+		c = exec.Command("xdg-open", target)
+	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	// the browser keeps running after we exit
+	return c.Process.Release()
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openCmd.Flags().Bool("archived", false, "open the latest snapshot instead of the url")
+}
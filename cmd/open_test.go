@@ -0,0 +1,75 @@
+package cmd_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/cmd"
+)
+
+func TestOpenTarget(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	snapshot := filepath.Join(dir, "ab", "abc.html")
+	tests := []struct {
+		name     string
+		bookmark *bookmark.Bookmark
+		archived bool
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "url",
+			bookmark: &bookmark.Bookmark{Content: "https://go.dev"},
+			want:     "https://go.dev",
+		},
+		{
+			name:     "note with a path",
+			bookmark: &bookmark.Bookmark{Content: "/usr/bin/xterm"},
+			wantErr:  true,
+		},
+		{
+			name:     "note with a file url",
+			bookmark: &bookmark.Bookmark{Content: "file:///etc/passwd"},
+			wantErr:  true,
+		},
+		{
+			name:     "snapshot",
+			bookmark: &bookmark.Bookmark{Content: "https://go.dev", Snapshot: bookmark.Snapshot{Path: snapshot}},
+			archived: true,
+			want:     snapshot,
+		},
+		{
+			name:     "snapshot of a note",
+			bookmark: &bookmark.Bookmark{Content: "note", Snapshot: bookmark.Snapshot{Path: snapshot}},
+			archived: true,
+			want:     snapshot,
+		},
+		{
+			name:     "no snapshot",
+			bookmark: &bookmark.Bookmark{Content: "https://go.dev"},
+			archived: true,
+			wantErr:  true,
+		},
+		{
+			name: "snapshot outside the archive",
+			bookmark: &bookmark.Bookmark{
+				Content:  "https://go.dev",
+				Snapshot: bookmark.Snapshot{Path: filepath.Join(dir, "..", "run.sh")},
+			},
+			archived: true,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cmd.OpenTarget(tt.bookmark, tt.archived, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("openTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// Supported output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputJSONL = "jsonl"
+	outputCSV   = "csv"
+	outputTSV   = "tsv"
+)
+
+//nolint:gochecknoglobals // static list of formats
+var outputFormats = []string{outputTable, outputJSON, outputJSONL, outputCSV, outputTSV}
+
+// field is a column of the output.
+type field struct {
+	name   string
+	header string
+	// value returns the value used in JSON output.
+	value func(b *bookmark.Bookmark) any
+	// text returns the value used in table, CSV and TSV output.
+	text func(b *bookmark.Bookmark) string
+}
+
+// fields are all fields that can be selected with --fields.
+//
+//nolint:gochecknoglobals // static list of fields
+var fields = []field{
+	{
+		name:   "id",
+		header: "ID",
+		value:  func(b *bookmark.Bookmark) any { return b.ID },
+		text:   func(b *bookmark.Bookmark) string { return b.ID },
+	},
+	{
+		name:   "title",
+		header: "Title",
+		value:  func(b *bookmark.Bookmark) any { return b.Title },
+		text:   func(b *bookmark.Bookmark) string { return b.Title },
+	},
+	{
+		name:   "content",
+		header: "Content",
+		value:  func(b *bookmark.Bookmark) any { return b.Content },
+		text:   func(b *bookmark.Bookmark) string { return b.Content },
+	},
+	{
+		name:   "tags",
+		header: "Tags",
+		value: func(b *bookmark.Bookmark) any {
+			if b.Tags == nil {
+				return []string{}
+			}
+			return b.Tags
+		},
+		text: func(b *bookmark.Bookmark) string { return strings.Join(b.Tags, ",") },
+	},
+	{
+		name:   "collection",
+		header: "Collection",
+		value:  func(b *bookmark.Bookmark) any { return b.Collection },
+		text:   func(b *bookmark.Bookmark) string { return b.Collection },
+	},
+	metadataField("description", "Description", func(m *bookmark.Metadata) string { return m.Description }),
+	metadataField("site", "Site", func(m *bookmark.Metadata) string { return m.SiteName }),
+	metadataField("canonical_url", "Canonical URL", func(m *bookmark.Metadata) string { return m.CanonicalURL }),
+	metadataField("favicon_url", "Favicon URL", func(m *bookmark.Metadata) string { return m.FaviconURL }),
+	metadataField("image_url", "Image URL", func(m *bookmark.Metadata) string { return m.ImageURL }),
+	metadataField("language", "Language", func(m *bookmark.Metadata) string { return m.Language }),
+	{
+		name:   "pending",
+		header: "Pending",
+		value:  func(b *bookmark.Bookmark) any { return b.PendingMetadata },
+		text:   func(b *bookmark.Bookmark) string { return strconv.FormatBool(b.PendingMetadata) },
+	},
+	{
+		name:   "status",
+		header: "Status",
+		value:  func(b *bookmark.Bookmark) any { return b.Health.StatusCode },
+		text: func(b *bookmark.Bookmark) string {
+			if b.Health.CheckedAt.IsZero() {
+				return ""
+			}
+			return statusText(b.Health)
+		},
+	},
+	{
+		name:   "final_url",
+		header: "Final URL",
+		value:  func(b *bookmark.Bookmark) any { return b.Health.FinalURL },
+		text:   func(b *bookmark.Bookmark) string { return b.Health.FinalURL },
+	},
+	{
+		name:   "failures",
+		header: "Failures",
+		value:  func(b *bookmark.Bookmark) any { return b.Health.Failures },
+		text:   func(b *bookmark.Bookmark) string { return strconv.Itoa(b.Health.Failures) },
+	},
+	{
+		name:   "checked_at",
+		header: "Checked At",
+		value:  func(b *bookmark.Bookmark) any { return timeValue(b.Health.CheckedAt) },
+		text:   func(b *bookmark.Bookmark) string { return timeText(b.Health.CheckedAt) },
+	},
+	{
+		name:   "snapshot",
+		header: "Snapshot",
+		value:  func(b *bookmark.Bookmark) any { return b.Snapshot.Path },
+		text:   func(b *bookmark.Bookmark) string { return b.Snapshot.Path },
+	},
+	{
+		name:   "created_at",
+		header: "Created At",
+		value:  func(b *bookmark.Bookmark) any { return timeValue(b.CreatedAt) },
+		text:   func(b *bookmark.Bookmark) string { return timeText(b.CreatedAt) },
+	},
+	{
+		name:   "updated_at",
+		header: "Updated At",
+		value:  func(b *bookmark.Bookmark) any { return timeValue(b.UpdatedAt) },
+		text:   func(b *bookmark.Bookmark) string { return timeText(b.UpdatedAt) },
+	},
+}
+
+// metadataField returns a field for a value of the page metadata.
+func metadataField(name, header string, value func(m *bookmark.Metadata) string) field {
+	return field{
+		name:   name,
+		header: header,
+		value:  func(b *bookmark.Bookmark) any { return value(&b.Metadata) },
+		text:   func(b *bookmark.Bookmark) string { return value(&b.Metadata) },
+	}
+}
+
+// defaultFields are the fields shown in tables when --fields is not set.
+//
+//nolint:gochecknoglobals // static list of fields
+var defaultFields = []string{"id", "title", "content", "tags", "created_at"}
+
+// outputOptions configure how bookmarks are printed.
+type outputOptions struct {
+	Format   string
+	Template string
+	Fields   []string
+}
+
+// addOutputFlags adds the flags read by outputFlags to the command.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", outputTable, "output format, one of "+strings.Join(outputFormats, ", "))
+	cmd.Flags().String("template", "", "Go text/template that is executed for every bookmark, overrides --output")
+	cmd.Flags().StringSlice("fields", nil, "fields to show, any of "+strings.Join(fieldNames(), ", "))
+}
+
+// outputFlags reads the output options of the command.
+func outputFlags(cmd *cobra.Command) (outputOptions, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return outputOptions{}, fmt.Errorf("failed to get output flag: %w", err)
+	}
+	tmpl, err := cmd.Flags().GetString("template")
+	if err != nil {
+		return outputOptions{}, fmt.Errorf("failed to get template flag: %w", err)
+	}
+	names, err := cmd.Flags().GetStringSlice("fields")
+	if err != nil {
+		return outputOptions{}, fmt.Errorf("failed to get fields flag: %w", err)
+	}
+	return outputOptions{Format: format, Template: tmpl, Fields: names}, nil
+}
+
+// printBookmarks prints the bookmarks in the configured format.
+func printBookmarks(w io.Writer, bookmarks []*bookmark.Bookmark, o outputOptions) error {
+	if o.Template != "" {
+		return printTemplate(w, bookmarks, o.Template)
+	}
+	selected, err := selectFields(o)
+	if err != nil {
+		return err
+	}
+	switch o.Format {
+	case outputTable, "":
+		return printTable(w, bookmarks, selected)
+	case outputJSON:
+		return printJSON(w, bookmarks, selected)
+	case outputJSONL:
+		return printJSONL(w, bookmarks, selected)
+	case outputCSV:
+		return printCSV(w, bookmarks, selected, ',')
+	case outputTSV:
+		return printTSV(w, bookmarks, selected)
+	default:
+		return fmt.Errorf("unknown output format %q, use one of %s", o.Format, strings.Join(outputFormats, ", "))
+	}
+}
+
+// selectFields returns the fields to print. JSON output includes all
+// fields by default, the other formats only the default fields.
+func selectFields(o outputOptions) ([]field, error) {
+	names := o.Fields
+	if len(names) == 0 {
+		names = defaultFields
+		if o.Format == outputJSON || o.Format == outputJSONL {
+			names = fieldNames()
+		}
+	}
+	selected := make([]field, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(fields, func(f field) bool { return f.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown field %q, use any of %s", name, strings.Join(fieldNames(), ", "))
+		}
+		selected = append(selected, fields[i])
+	}
+	return selected, nil
+}
+
+// fieldNames returns the names of all fields.
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// printTable prints the bookmarks as an aligned table. Nothing is printed
+// when there are no bookmarks.
+func printTable(w io.Writer, bookmarks []*bookmark.Bookmark, selected []field) error {
+	rows := make([][]string, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		rows = append(rows, tableRow(b, selected, nil))
+	}
+	return writeTable(w, selected, rows)
+}
+
+// printSearchResults prints search results like printBookmarks. Tables
+// highlight the parts of the fields that match the query.
+func printSearchResults(w io.Writer, results []bookmark.SearchResult, o outputOptions) error {
+	if o.Template != "" || (o.Format != outputTable && o.Format != "") {
+		return printBookmarks(w, bookmark.Bookmarks(results), o)
+	}
+	selected, err := selectFields(o)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, tableRow(r.Bookmark, selected, r.Matches))
+	}
+	return writeTable(w, selected, rows)
+}
+
+// tableRow returns the cells of a bookmark in a table, with the matches
+// highlighted.
+func tableRow(b *bookmark.Bookmark, selected []field, matches []bookmark.Match) []string {
+	row := make([]string, 0, len(selected))
+	for _, f := range selected {
+		// escaping keeps the length of the text, so ranges stay valid
+		text := tsvEscaper.Replace(f.text(b))
+		if i := slices.IndexFunc(matches, func(m bookmark.Match) bool { return m.Field == f.name }); i >= 0 {
+			text = highlightRanges(text, matches[i].Ranges)
+		}
+		row = append(row, text)
+	}
+	return row
+}
+
+// writeTable writes the rows below a header with the columns aligned.
+// Styled cells are aligned by the width they are shown in. Nothing is
+// written when there are no rows.
+func writeTable(w io.Writer, selected []field, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	headers := make([]string, 0, len(selected))
+	for _, f := range selected {
+		headers = append(headers, f.header)
+	}
+	rows = append([][]string{headers}, rows...)
+	widths := make([]int, len(selected))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			b.WriteString(cell)
+			// the last column is not padded
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(cell)+padding))
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// printJSON prints the bookmarks as a JSON array of objects.
+func printJSON(w io.Writer, bookmarks []*bookmark.Bookmark, selected []field) error {
+	objects := make([]jsonObject, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		objects = append(objects, object(b, selected))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+// printJSONL prints every bookmark as a JSON object on its own line.
+func printJSONL(w io.Writer, bookmarks []*bookmark.Bookmark, selected []field) error {
+	enc := json.NewEncoder(w)
+	for _, b := range bookmarks {
+		if err := enc.Encode(object(b, selected)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printCSV prints the bookmarks as CSV with a header row.
+func printCSV(w io.Writer, bookmarks []*bookmark.Bookmark, selected []field, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	headers := make([]string, 0, len(selected))
+	for _, f := range selected {
+		headers = append(headers, f.name)
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if err := cw.Write(texts(b, selected)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper replaces the characters that would break TSV rows.
+//
+//nolint:gochecknoglobals // a replacer is safe for concurrent use
+var tsvEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// printTSV prints the bookmarks as tab separated values with a header row.
+// Unlike CSV, values are never quoted, tabs and newlines become spaces.
+func printTSV(w io.Writer, bookmarks []*bookmark.Bookmark, selected []field) error {
+	headers := make([]string, 0, len(selected))
+	for _, f := range selected {
+		headers = append(headers, f.name)
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(headers, "\t")); err != nil {
+		return err
+	}
+	for _, b := range bookmarks {
+		if _, err := fmt.Fprintln(w, tsvRow(b, selected)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tsvRow returns the tab separated text values of the selected fields.
+func tsvRow(b *bookmark.Bookmark, selected []field) string {
+	values := texts(b, selected)
+	for i, v := range values {
+		values[i] = tsvEscaper.Replace(v)
+	}
+	return strings.Join(values, "\t")
+}
+
+// printTemplate executes the template for every bookmark. A newline is
+// added after every bookmark unless the template ends with one.
+func printTemplate(w io.Writer, bookmarks []*bookmark.Bookmark, text string) error {
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	for _, b := range bookmarks {
+		if err = tmpl.Execute(w, b); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		if !strings.HasSuffix(text, "\n") {
+			if _, err = io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// texts returns the text values of the selected fields.
+func texts(b *bookmark.Bookmark, selected []field) []string {
+	values := make([]string, 0, len(selected))
+	for _, f := range selected {
+		values = append(values, f.text(b))
+	}
+	return values
+}
+
+// jsonObject is a JSON object that keeps the order of its fields.
+type jsonObject []jsonMember
+
+// jsonMember is a member of a jsonObject.
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, m := range o {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// object returns the JSON object of the selected fields.
+func object(b *bookmark.Bookmark, selected []field) jsonObject {
+	o := make(jsonObject, 0, len(selected))
+	for _, f := range selected {
+		o = append(o, jsonMember{key: f.name, value: f.value(b)})
+	}
+	return o
+}
+
+// timeValue returns the JSON value of a time, null for the zero time.
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// timeText returns the text of a time, empty for the zero time.
+func timeText(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateTime)
+}
@@ -0,0 +1,120 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/cmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintBookmarks(t *testing.T) {
+	bookmarks := []*bookmark.Bookmark{
+		{
+			ID:        "01JQ0000000000000000000001",
+			Title:     "Go, \"the\" language",
+			Content:   "https://go.dev",
+			Tags:      []string{"go", "docs"},
+			CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:      "01JQ0000000000000000000002",
+			Title:   "Note",
+			Content: "first line\nsecond\tcolumn",
+		},
+	}
+	tests := []struct {
+		name    string
+		opts    cmd.OutputOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name: "table should print the default fields",
+			opts: cmd.OutputOptions{Format: "table"},
+			want: "ID                         Title              Content                  Tags    Created At\n" +
+				"01JQ0000000000000000000001 Go, \"the\" language https://go.dev           go,docs 2025-03-01 12:00:00\n" +
+				"01JQ0000000000000000000002 Note               first line second column         \n",
+		},
+		{
+			name: "json should print the selected fields in order",
+			opts: cmd.OutputOptions{Format: "json", Fields: []string{"tags", "id", "created_at"}},
+			want: `[
+  {
+    "tags": [
+      "go",
+      "docs"
+    ],
+    "id": "01JQ0000000000000000000001",
+    "created_at": "2025-03-01T12:00:00Z"
+  },
+  {
+    "tags": [],
+    "id": "01JQ0000000000000000000002",
+    "created_at": null
+  }
+]
+`,
+		},
+		{
+			name: "jsonl should print an object per line",
+			opts: cmd.OutputOptions{Format: "jsonl", Fields: []string{"id", "content"}},
+			want: `{"id":"01JQ0000000000000000000001","content":"https://go.dev"}` + "\n" +
+				`{"id":"01JQ0000000000000000000002","content":"first line\nsecond\tcolumn"}` + "\n",
+		},
+		{
+			name: "csv should quote values with commas, quotes and newlines",
+			opts: cmd.OutputOptions{Format: "csv", Fields: []string{"id", "title", "content"}},
+			want: "id,title,content\n" +
+				"01JQ0000000000000000000001,\"Go, \"\"the\"\" language\",https://go.dev\n" +
+				"01JQ0000000000000000000002,Note,\"first line\nsecond\tcolumn\"\n",
+		},
+		{
+			name: "tsv should replace tabs and newlines",
+			opts: cmd.OutputOptions{Format: "tsv", Fields: []string{"id", "content", "tags"}},
+			want: "id\tcontent\ttags\n" +
+				"01JQ0000000000000000000001\thttps://go.dev\tgo,docs\n" +
+				"01JQ0000000000000000000002\tfirst line second column\t\n",
+		},
+		{
+			name: "template should be executed for every bookmark",
+			opts: cmd.OutputOptions{Format: "json", Template: `{{.Title}} [{{join .Tags ","}}]`},
+			want: "Go, \"the\" language [go,docs]\nNote []\n",
+		},
+		{
+			name:    "unknown fields should fail",
+			opts:    cmd.OutputOptions{Format: "csv", Fields: []string{"id", "url"}},
+			wantErr: `unknown field "url"`,
+		},
+		{
+			name:    "unknown formats should fail",
+			opts:    cmd.OutputOptions{Format: "xml"},
+			wantErr: `unknown output format "xml"`,
+		},
+		{
+			name:    "invalid templates should fail",
+			opts:    cmd.OutputOptions{Template: "{{.Title"},
+			wantErr: "invalid template",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := cmd.PrintBookmarks(&b, bookmarks, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("printBookmarks() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("printBookmarks() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("printBookmarks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
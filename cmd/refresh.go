@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// refreshCmd represents the refresh command
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Fetch the metadata of bookmarks again",
+	Long: `Fetch the metadata of bookmarks again. Without flags only bookmarks with pending metadata are refreshed,
+which are bookmarks that were saved offline or whose page could not be fetched.
+Titles are replaced by the title of the page unless they were set by hand.`,
+	Args: cobra.NoArgs,
+	RunE: runRefreshCmd,
+}
+
+// runRefreshCmd represents the command to run when the refresh command is specified
+func runRefreshCmd(cmd *cobra.Command, _ []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return fmt.Errorf("failed to get all flag: %w", err)
+	}
+	stale, err := parseAge(cmd.Flag("stale").Value.String())
+	if err != nil {
+		return fmt.Errorf("invalid stale flag: %w", err)
+	}
+	tags, err := cmd.Flags().GetStringSlice("tag")
+	if err != nil {
+		return fmt.Errorf("failed to get tag flag: %w", err)
+	}
+	opts := bookmark.RefreshOptions{}
+	if opts.Concurrency, err = cmd.Flags().GetInt("concurrency"); err != nil {
+		return fmt.Errorf("failed to get concurrency flag: %w", err)
+	}
+	if opts.HostInterval, err = cmd.Flags().GetDuration("host-interval"); err != nil {
+		return fmt.Errorf("failed to get host-interval flag: %w", err)
+	}
+	if opts.DryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+		return fmt.Errorf("failed to get dry-run flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	bookmarks, err := refreshSelection(lib, all, stale, tags)
+	if err != nil {
+		return err
+	}
+	opts.Progress = progressBar(cmd.ErrOrStderr())
+	var refreshed, failed int
+	for _, r := range lib.Refresh(cmd.Context(), bookmarks, opts) {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(cmd.ErrOrStderr(), "could not refresh %s: %v\n", r.Bookmark.ID, r.Err)
+			continue
+		}
+		refreshed++
+		if r.TitleChanged() {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %q -> %q\n", r.Bookmark.ID, r.Bookmark.Title, r.Updated.Title)
+		}
+	}
+	if opts.DryRun {
+		fmt.Fprintf(cmd.OutOrStdout(), "would refresh %d, failed %d\n", refreshed, failed)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "refreshed %d, failed %d\n", refreshed, failed)
+	return nil
+}
+
+// refreshSelection returns the bookmarks selected by the flags of the
+// refresh command. Without flags the pending bookmarks are selected.
+func refreshSelection(lib *bookmark.Library, all bool, stale time.Duration, tags []string) ([]*bookmark.Bookmark, error) {
+	var bookmarks []*bookmark.Bookmark
+	var err error
+	switch {
+	case stale > 0:
+		bookmarks, err = lib.Stale(stale)
+	case all || len(tags) > 0:
+		bookmarks, err = lib.List()
+	default:
+		bookmarks, err = lib.Pending()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks: %w", err)
+	}
+	return slices.DeleteFunc(bookmarks, func(b *bookmark.Bookmark) bool {
+		return !b.HasURL() || !b.HasTags(tags...)
+	}), nil
+}
+
+// parseAge parses a duration that may also be given in days or weeks, like
+// 30d or 2w. An empty string is zero.
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	const day = 24 * time.Hour
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": 7 * day} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// progressBar returns a progress function that draws a bar on w. It returns
+// nil when w is not a terminal, so no bar ends up in logs or pipes.
+func progressBar(w io.Writer) func(done, total int) {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(f.Fd()) {
+		return nil
+	}
+	const width = 30
+	return func(done, total int) {
+		filled := width * done / total
+		fmt.Fprintf(w, "\r[%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat(" ", width-filled), done, total)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().Bool("all", false, "refresh all bookmarks of urls")
+	refreshCmd.Flags().String("stale", "", "refresh bookmarks fetched longer ago than this, like 30d, 2w or 12h")
+	refreshCmd.Flags().StringSlice("tag", nil, "only refresh bookmarks with this tag, can be repeated")
+	refreshCmd.Flags().IntP("concurrency", "c", bookmark.DefaultRefreshConcurrency, "number of pages to fetch at once")
+	refreshCmd.Flags().Duration("host-interval", time.Second, "minimum time between two fetches from the same host")
+	refreshCmd.Flags().Bool("dry-run", false, "show the title changes without saving them")
+	refreshCmd.MarkFlagsMutuallyExclusive("all", "stale")
+}
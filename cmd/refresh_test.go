@@ -0,0 +1,36 @@
+package cmd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DWethmar/bookmarks/cmd"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{age: "", want: 0},
+		{age: "30d", want: 30 * 24 * time.Hour},
+		{age: "2w", want: 14 * 24 * time.Hour},
+		{age: "12h", want: 12 * time.Hour},
+		{age: "1h30m", want: 90 * time.Minute},
+		{age: "-1d", wantErr: true},
+		{age: "xd", wantErr: true},
+		{age: "30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			got, err := cmd.ParseAge(tt.age)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// reindexCmd represents the reindex command
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the search index",
+	Long: `Rebuild the search index from all bookmarks. The index is kept up to date by all commands,
+rebuilding it is only needed when the store was changed by something else.`,
+	Args: cobra.NoArgs,
+	RunE: runReindexCmd,
+}
+
+// runReindexCmd represents the command to run when the reindex command is specified
+func runReindexCmd(cmd *cobra.Command, _ []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	n, err := lib.Reindex()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to rebuild search index: %w", err), lib.Close())
+	}
+	// the index is written when the library is closed
+	if err = lib.Close(); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "indexed %d bookmarks\n", n)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
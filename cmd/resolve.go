@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
+)
+
+// resolveBookmarks resolves the given IDs or titles to bookmarks. Every
+// bookmark is returned once, even if it is referenced multiple times.
+func resolveBookmarks(lib *bookmark.Library, refs []string) ([]*bookmark.Bookmark, error) {
+	var results []*bookmark.Bookmark
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		bookmarks, err := lib.Resolve(ref)
+		if errors.Is(err, json.ErrNotFound) {
+			return nil, fmt.Errorf("no bookmark with id or title %q: %w", ref, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find bookmark %q: %w", ref, err)
+		}
+		for _, b := range bookmarks {
+			if !seen[b.ID] {
+				seen[b.ID] = true
+				results = append(results, b)
+			}
+		}
+	}
+	return results, nil
+}
+
+// resolveBookmark resolves the given ID or title to exactly one bookmark.
+func resolveBookmark(lib *bookmark.Library, ref string) (*bookmark.Bookmark, error) {
+	bookmarks, err := resolveBookmarks(lib, []string{ref})
+	if err != nil {
+		return nil, err
+	}
+	if len(bookmarks) > 1 {
+		return nil, fmt.Errorf("%d bookmarks are titled %q, use the id instead", len(bookmarks), ref)
+	}
+	return bookmarks[0], nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// rmCmd represents the rm command
+var rmCmd = &cobra.Command{
+	Use:   "rm <id|title>...",
+	Short: "Remove bookmarks",
+	Long:  "Remove one or more bookmarks by id or title from the bookmark manager",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runRmCmd,
+}
+
+// runRmCmd represents the command to run when the rm command is specified
+func runRmCmd(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return fmt.Errorf("failed to get force flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	bookmarks, err := resolveBookmarks(lib, args)
+	if err != nil {
+		return err
+	}
+	if !force {
+		table(cmd.OutOrStdout(), bookmarks)
+		ok, cErr := confirm(cmd.InOrStdin(), cmd.OutOrStdout(), fmt.Sprintf("Remove %d bookmark(s)?", len(bookmarks)))
+		if cErr != nil {
+			return cErr
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+	for _, b := range bookmarks {
+		if err = lib.Delete(b.ID); err != nil {
+			return fmt.Errorf("failed to remove bookmark %s: %w", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// confirm asks the user a yes/no question. Anything but yes counts as no.
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().BoolP("force", "f", false, "remove without asking for confirmation")
+}
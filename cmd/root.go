@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/DWethmar/bookmarks/bookmark/json"
 	"github.com/DWethmar/bookmarks/ui"
 	"github.com/spf13/cobra"
 )
@@ -16,31 +18,35 @@ var rootCmd = &cobra.Command{
 	Short: "A bookmark manager for webresources",
 	Long:  `A bookmark manager for webresources, that allows you to save, search and delete bookmarks`,
 	RunE:  runRootCmd,
+	// errors are reported by the commands themselves, usage is only noise
+	SilenceUsage: true,
 }
 
 // runRootCmd represents the command to run when no subcommands are specified
 func runRootCmd(cmd *cobra.Command, _ []string) error {
 	var err error
-	lib, err := setupBookmarks(loadLibraryOptions{
-		Verbose: cmd.Flag("verbose").Changed,
-		DBName:  appName,
-	})
+	lib, err := setupBookmarks(libraryOptions(cmd))
 	if err != nil {
 		return err
 	}
+	defer lib.Close()
 	// if a query is provided, search for bookmarks
 	if q := cmd.Flag("search").Value.String(); q != "" {
-		bookmarks, sErr := lib.Search(q)
+		output, oErr := outputFlags(cmd)
+		if oErr != nil {
+			return oErr
+		}
+		opts, oErr := searchFlags(cmd)
+		if oErr != nil {
+			return oErr
+		}
+		results, sErr := lib.Search(q, opts)
 		if sErr != nil {
 			return fmt.Errorf("failed to search bookmarks: %w", sErr)
 		}
-		if len(bookmarks) == 0 {
-			return nil
-		}
-		table(bookmarks)
-		return nil
+		return printSearchResults(cmd.OutOrStdout(), results, output)
 	}
-	return ui.Run(lib)
+	return ui.Run(cmd.Context(), lib)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -54,5 +60,17 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
-	rootCmd.Flags().StringP("search", "s", "", "search for bookmarks")
+	rootCmd.PersistentFlags().String("store", storeJSON, "store to keep bookmarks in, json or sqlite")
+	rootCmd.PersistentFlags().Duration("lock-timeout", json.DefaultLockTimeout,
+		"how long to wait for other processes using the json store")
+	rootCmd.PersistentFlags().Duration("fetch-timeout", bookmark.DefaultFetchTimeout,
+		"time limit of fetching a page, 0 for no limit")
+	rootCmd.PersistentFlags().Int64("max-page-size", bookmark.DefaultMaxBodyBytes,
+		"largest page in bytes that is fetched, 0 for no limit")
+	rootCmd.PersistentFlags().Bool("deny-private-networks", false,
+		"refuse to fetch pages on addresses that are not public, like localhost and cloud metadata endpoints")
+	addCanonicalFlags(rootCmd)
+	rootCmd.Flags().StringP("search", "s", "", "search for bookmarks with a query, see search --help")
+	addModeFlag(rootCmd)
+	addOutputFlags(rootCmd)
 }
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DWethmar/bookmarks/bookmark/query"
+	"github.com/spf13/cobra"
+)
+
+// savedCmd represents the saved command
+var savedCmd = &cobra.Command{
+	Use:   "saved",
+	Short: "Manage saved searches",
+	Long: `Save search queries under a name and run them again later.
+Saved searches are queries, not copies, so they always find the bookmarks that match now.`,
+}
+
+// savedSaveCmd represents the saved save command
+var savedSaveCmd = &cobra.Command{
+	Use:   "save <name> <query>...",
+	Short: "Save a search",
+	Long: `Save a search query under a name, replacing the saved search with the same name.
+The query is run again every time the saved search is used, so it always lists the bookmarks
+that match now. Saved searches also show up as folders in the interactive list.`,
+	Example: `  bookmarks saved save reading tag:to-read -tag:done
+  bookmarks saved save --mode fuzzy gh gthb
+  bookmarks saved save -- untagged '-tag:*'`,
+	Args: cobra.MinimumNArgs(2), //nolint:mnd // a name and at least one query term
+	RunE: runSavedSaveCmd,
+}
+
+// savedLsCmd represents the saved ls command
+var savedLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List saved searches",
+	Long:  "List the saved searches with their queries",
+	Args:  cobra.NoArgs,
+	RunE:  runSavedLsCmd,
+}
+
+// savedRunCmd represents the saved run command
+var savedRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a saved search",
+	Long:  "Search bookmarks with the query of a saved search, most relevant first",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSavedRunCmd,
+}
+
+// savedRmCmd represents the saved rm command
+var savedRmCmd = &cobra.Command{
+	Use:   "rm <name>...",
+	Short: "Remove saved searches",
+	Long:  "Remove one or more saved searches by name, the bookmarks they match are kept",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSavedRmCmd,
+}
+
+// runSavedSaveCmd represents the command to run when the saved save command is specified
+func runSavedSaveCmd(cmd *cobra.Command, args []string) error {
+	opts, err := searchFlags(cmd)
+	if err != nil {
+		return err
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	if _, err = lib.SaveSearch(args[0], strings.Join(args[1:], " "), opts); err != nil {
+		return fmt.Errorf("failed to save search %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// runSavedLsCmd represents the command to run when the saved ls command is specified
+func runSavedLsCmd(cmd *cobra.Command, _ []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	searches, err := lib.SavedSearches()
+	if err != nil {
+		return fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "Name\tMode\tQuery")
+	for _, s := range searches {
+		mode := string(s.Mode)
+		if mode == "" {
+			mode = string(query.ModeSubstring)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, mode, s.Query)
+	}
+	tw.Flush()
+	return nil
+}
+
+// runSavedRunCmd represents the command to run when the saved run command is specified
+func runSavedRunCmd(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("failed to get limit flag: %w", err)
+	}
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("failed to get output flag: %w", err)
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	results, err := lib.RunSearch(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to run search %s: %w", args[0], err)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return printResults(cmd.OutOrStdout(), results, format)
+}
+
+// runSavedRmCmd represents the command to run when the saved rm command is specified
+func runSavedRmCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	for _, name := range args {
+		if err = lib.DeleteSearch(name); err != nil {
+			return fmt.Errorf("failed to remove search %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(savedCmd)
+	savedCmd.AddCommand(savedSaveCmd)
+	savedCmd.AddCommand(savedLsCmd)
+	savedCmd.AddCommand(savedRunCmd)
+	savedCmd.AddCommand(savedRmCmd)
+	addModeFlag(savedSaveCmd)
+	savedRunCmd.Flags().IntP("limit", "n", defaultSearchLimit, "maximum number of results, 0 shows all")
+	savedRunCmd.Flags().StringP("output", "o", outputText,
+		"output format, one of "+strings.Join([]string{outputText, outputJSON, outputJSONL}, ", "))
+}
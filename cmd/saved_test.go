@@ -0,0 +1,35 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DWethmar/bookmarks/cmd"
+)
+
+func TestSavedCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"search", "save"}, want: "search"},
+		{args: []string{"search", "ls"}, want: "search"},
+		{args: []string{"search", "run"}, want: "search"},
+		{args: []string{"search", "rm"}, want: "search"},
+		{args: []string{"saved", "save", "reading", "tag:to-read"}, want: "saved save"},
+		{args: []string{"saved", "ls"}, want: "saved ls"},
+		{args: []string{"saved", "run", "reading"}, want: "saved run"},
+		{args: []string{"saved", "rm", "reading"}, want: "saved rm"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			c, _, err := cmd.RootCmd.Find(tt.args)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if got := strings.TrimPrefix(c.CommandPath(), cmd.RootCmd.Name()+" "); got != tt.want {
+				t.Errorf("Find() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// outputText is the output format of search that lists results with their
+// snippets.
+const outputText = "text"
+
+// defaultSearchLimit is the number of results search shows by default.
+const defaultSearchLimit = 10
+
+// highlightStyle marks the matches in snippets. It renders plain text when
+// the output is not a terminal.
+//
+//nolint:gochecknoglobals // styles are static
+var highlightStyle = lipgloss.NewStyle().Bold(true)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <query>...",
+	Short: "Search bookmarks by relevance",
+	Long: `Search the title, description, tags, url and page text of bookmarks, most relevant first.
+Words match regardless of case, accents and word endings, so "operator" also finds "Operators".
+A word that ends with * matches all words that start with it.
+
+Every term of the query must match. Terms can be combined with AND, OR, NOT and parentheses,
+a - before a term is short for NOT. Besides words there are:
+
+  "quoted phrases"       match text exactly, ignoring case
+  title:go, url:blog     match a single field
+  site:github.com        match bookmarks of a site and its subdomains
+  tag:go                 match bookmarks with the tag, tag:* bookmarks with any tag
+  created:>2025-01-01    match creation dates, also updated: for the last update
+
+Dates are days, months like 2025-01, years, ranges like 2025-01..2025-03, or one of today,
+yesterday, last-week, last-month and last-year, optionally prefixed by >, >=, < or <=.
+
+With --mode fuzzy words match when their characters appear in order, like "gthb" in "GitHub",
+and with --mode regex they are regular expressions. Both rank by how well words match, as the
+index is only used for substrings. Quote expressions with spaces or parentheses.
+
+Searches can be saved under a name with saved save, and run again with saved run.`,
+	Example: `  bookmarks search site:github.com (go OR rust) -tag:archived
+  bookmarks search title:"release notes" created:last-month`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearchCmd,
+}
+
+// runSearchCmd represents the command to run when the search command is specified
+func runSearchCmd(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("failed to get limit flag: %w", err)
+	}
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("failed to get output flag: %w", err)
+	}
+	opts, err := searchFlags(cmd)
+	if err != nil {
+		return err
+	}
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	results, err := lib.Search(strings.Join(args, " "), opts)
+	if err != nil {
+		return fmt.Errorf("failed to search bookmarks: %w", err)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return printResults(cmd.OutOrStdout(), results, format)
+}
+
+// printResults prints search results in the given format.
+func printResults(w io.Writer, results []bookmark.SearchResult, format string) error {
+	switch format {
+	case outputText, "":
+		for i, r := range results {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			printResult(w, r)
+		}
+		return nil
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		objects := make([]resultObject, 0, len(results))
+		for _, r := range results {
+			objects = append(objects, newResultObject(r))
+		}
+		return enc.Encode(objects)
+	case outputJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			if err := enc.Encode(newResultObject(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use %s, %s or %s", format, outputText, outputJSON, outputJSONL)
+	}
+}
+
+// printResult prints the score, title, ID and URL of a result followed by
+// its snippets of the description and body.
+func printResult(w io.Writer, r bookmark.SearchResult) {
+	title := r.Bookmark.Title
+	for _, m := range r.Matches {
+		if m.Field == "title" {
+			title = highlightRanges(title, m.Ranges)
+		}
+	}
+	for _, s := range r.Snippets {
+		if s.Field == "title" {
+			title = highlight(s)
+		}
+	}
+	fmt.Fprintf(w, "%s  %s\n", strconv.FormatFloat(r.Score, 'f', 2, 64), title)
+	// the content of other bookmarks is shown as body snippet
+	if r.Bookmark.HasURL() {
+		fmt.Fprintf(w, "      %s  %s\n", r.Bookmark.ID, r.Bookmark.Content)
+	} else {
+		fmt.Fprintf(w, "      %s\n", r.Bookmark.ID)
+	}
+	for _, s := range r.Snippets {
+		if s.Field != "title" {
+			fmt.Fprintf(w, "      %s\n", highlight(s))
+		}
+	}
+}
+
+// highlight renders a snippet with its highlights in bold.
+func highlight(s bookmark.Snippet) string {
+	return highlightRanges(s.Text, s.Highlights)
+}
+
+// highlightRanges renders the ranges of the text in bold.
+func highlightRanges(text string, ranges []bookmark.Range) string {
+	var b strings.Builder
+	var last int
+	for _, r := range ranges {
+		b.WriteString(text[last:r.Start])
+		b.WriteString(highlightStyle.Render(text[r.Start:r.End]))
+		last = r.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// resultObject is the JSON output of a search result.
+type resultObject struct {
+	ID       string          `json:"id"`
+	Title    string          `json:"title"`
+	Content  string          `json:"content"`
+	Score    float64         `json:"score"`
+	Matches  []matchObject   `json:"matches"`
+	Snippets []snippetObject `json:"snippets"`
+}
+
+// matchObject is the JSON output of the matches in a field of a bookmark.
+// Ranges are the start and end byte offsets of the matches in the field.
+type matchObject struct {
+	Field  string   `json:"field"`
+	Ranges [][2]int `json:"ranges"`
+}
+
+// snippetObject is the JSON output of a snippet. Highlights are the start
+// and end byte offsets of the matches in the text.
+type snippetObject struct {
+	Field      string   `json:"field"`
+	Text       string   `json:"text"`
+	Highlights [][2]int `json:"highlights"`
+}
+
+// newResultObject returns the JSON output of a search result.
+func newResultObject(r bookmark.SearchResult) resultObject {
+	o := resultObject{
+		ID:       r.Bookmark.ID,
+		Title:    r.Bookmark.Title,
+		Content:  r.Bookmark.Content,
+		Score:    r.Score,
+		Matches:  []matchObject{},
+		Snippets: []snippetObject{},
+	}
+	for _, m := range r.Matches {
+		o.Matches = append(o.Matches, matchObject{Field: m.Field, Ranges: pairs(m.Ranges)})
+	}
+	for _, s := range r.Snippets {
+		o.Snippets = append(o.Snippets, snippetObject{Field: s.Field, Text: s.Text, Highlights: pairs(s.Highlights)})
+	}
+	return o
+}
+
+// pairs returns the ranges as pairs of offsets.
+func pairs(ranges []bookmark.Range) [][2]int {
+	p := make([][2]int, 0, len(ranges))
+	for _, r := range ranges {
+		p = append(p, [2]int{r.Start, r.End})
+	}
+	return p
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().IntP("limit", "n", defaultSearchLimit, "maximum number of results, 0 shows all")
+	addModeFlag(searchCmd)
+	searchCmd.Flags().StringP("output", "o", outputText,
+		"output format, one of "+strings.Join([]string{outputText, outputJSON, outputJSONL}, ", "))
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag <id|title> <tag>...",
+	Short: "Tag a bookmark",
+	Long:  "Add one or more tags to a bookmark",
+	Args:  cobra.MinimumNArgs(2), //nolint:mnd // a bookmark and at least one tag
+	RunE:  runTagCmd,
+}
+
+// untagCmd represents the untag command
+var untagCmd = &cobra.Command{
+	Use:   "untag <id|title> <tag>...",
+	Short: "Untag a bookmark",
+	Long:  "Remove one or more tags from a bookmark",
+	Args:  cobra.MinimumNArgs(2), //nolint:mnd // a bookmark and at least one tag
+	RunE:  runUntagCmd,
+}
+
+// tagsCmd represents the tags command
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List all tags",
+	Long:  "List all tags with the number of bookmarks that have them",
+	RunE:  runTagsCmd,
+}
+
+// runTagCmd represents the command to run when the tag command is specified
+func runTagCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	b, err := resolveBookmark(lib, args[0])
+	if err != nil {
+		return err
+	}
+	if _, err = lib.Tag(b.ID, args[1:]...); err != nil {
+		return fmt.Errorf("failed to tag bookmark %s: %w", b.ID, err)
+	}
+	return nil
+}
+
+// runUntagCmd represents the command to run when the untag command is specified
+func runUntagCmd(cmd *cobra.Command, args []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	b, err := resolveBookmark(lib, args[0])
+	if err != nil {
+		return err
+	}
+	if _, err = lib.Untag(b.ID, args[1:]...); err != nil {
+		return fmt.Errorf("failed to untag bookmark %s: %w", b.ID, err)
+	}
+	return nil
+}
+
+// runTagsCmd represents the command to run when the tags command is specified
+func runTagsCmd(cmd *cobra.Command, _ []string) error {
+	lib, err := setupBookmarks(libraryOptions(cmd))
+	if err != nil {
+		return err
+	}
+	defer lib.Close()
+	tags, err := lib.Tags()
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "Tag\tCount")
+	for _, t := range tags {
+		fmt.Fprintf(tw, "%s\t%d\n", t.Tag, t.Count)
+	}
+	tw.Flush()
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(untagCmd)
+	rootCmd.AddCommand(tagsCmd)
+}
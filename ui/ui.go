@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/DWethmar/bookmarks/bookmark"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,12 +22,33 @@ var (
 	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
 	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
 	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	siteStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
 	helpStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
 	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
+	folderStyle       = lipgloss.NewStyle().PaddingLeft(2)
+	activeFolderStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	searchFolderStyle = lipgloss.NewStyle().Italic(true)
+	sidebarStyle      = lipgloss.NewStyle().MarginTop(1).PaddingRight(2).
+				Border(lipgloss.NormalBorder(), false, true, false, false).BorderForeground(lipgloss.Color("241"))
 )
 
-type item string
+// allBookmarks is the title of the folder with all bookmarks.
+const allBookmarks = "My bookmarks"
+
+// folder is an entry of the sidebar. Folders of collections list the
+// bookmarks in the collection and the collections inside it, folders of
+// saved searches list the bookmarks that match the search when they are
+// opened. The folder of the root collection lists all bookmarks.
+type folder struct {
+	name       string
+	collection string
+	search     string
+}
+
+type item struct {
+	bookmark *bookmark.Bookmark
+}
 
 func (i item) FilterValue() string { return "" }
 
@@ -38,7 +63,7 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		return
 	}
 
-	str := fmt.Sprintf("%d. %s", index+1, i)
+	str := fmt.Sprintf("%d. %s", index+1, i.bookmark.Title)
 
 	fn := itemStyle.Render
 	if index == m.Index() {
@@ -48,10 +73,73 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	}
 
 	fmt.Fprint(w, fn(str))
+	if site := i.bookmark.Metadata.SiteName; site != "" {
+		fmt.Fprint(w, siteStyle.Render(" · "+site))
+	}
+	if i.bookmark.PendingMetadata {
+		fmt.Fprint(w, siteStyle.Render(" · pending"))
+	}
+}
+
+// refreshedMsg holds the bookmarks whose pending metadata was fetched.
+type refreshedMsg []*bookmark.Bookmark
+
+// refreshPending fetches the pending metadata of the bookmarks until the
+// context is cancelled.
+func refreshPending(ctx context.Context, lib *bookmark.Library, bookmarks []*bookmark.Bookmark) refreshedMsg {
+	var refreshed refreshedMsg
+	for _, r := range lib.Refresh(ctx, bookmarks, bookmark.RefreshOptions{}) {
+		if r.Err == nil {
+			refreshed = append(refreshed, r.Updated)
+		}
+	}
+	return refreshed
+}
+
+// pendingBookmarks returns the bookmarks with pending metadata.
+func pendingBookmarks(bookmarks []*bookmark.Bookmark) []*bookmark.Bookmark {
+	var pending []*bookmark.Bookmark
+	for _, b := range bookmarks {
+		if b.PendingMetadata {
+			pending = append(pending, b)
+		}
+	}
+	return pending
+}
+
+// folderMsg holds the bookmarks of the opened folder.
+type folderMsg struct {
+	folder    int
+	bookmarks []*bookmark.Bookmark
+	err       error
+}
+
+// openFolder lists the bookmarks of a folder in the background.
+func openFolder(lib *bookmark.Library, folders []folder, i int) tea.Cmd {
+	return func() tea.Msg {
+		msg := folderMsg{folder: i}
+		if folders[i].search == "" {
+			bookmarks, err := lib.List()
+			msg.bookmarks, msg.err = slices.DeleteFunc(bookmarks, func(b *bookmark.Bookmark) bool {
+				return !b.InCollection(folders[i].collection)
+			}), err
+			return msg
+		}
+		results, err := lib.RunSearch(folders[i].search)
+		msg.bookmarks, msg.err = bookmark.Bookmarks(results), err
+		return msg
+	}
 }
 
 type model struct {
-	list     list.Model
+	lib  *bookmark.Library
+	list list.Model
+	// folders are shown in a sidebar when there are collections or saved
+	// searches.
+	folders  []folder
+	folder   int
+	sidebar  bool // whether the sidebar has focus
+	err      error
 	choice   string
 	quitting bool
 }
@@ -63,7 +151,31 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
+		m.list.SetWidth(msg.Width - lipgloss.Width(m.sidebarView()))
+		return m, nil
+
+	case folderMsg:
+		if msg.folder != m.folder {
+			// another folder was opened in the meantime
+			return m, nil
+		}
+		m.err = msg.err
+		items := make([]list.Item, 0, len(msg.bookmarks))
+		for _, b := range msg.bookmarks {
+			items = append(items, item{bookmark: b})
+		}
+		m.list.Title = m.folders[m.folder].name
+		m.list.ResetSelected()
+		return m, m.list.SetItems(items)
+
+	case refreshedMsg:
+		for _, b := range msg {
+			for i, li := range m.list.Items() {
+				if it, ok := li.(item); ok && it.bookmark.ID == b.ID {
+					m.list.SetItem(i, item{bookmark: b})
+				}
+			}
+		}
 		return m, nil
 
 	case tea.KeyMsg:
@@ -72,10 +184,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 
+		case "tab":
+			m.sidebar = len(m.folders) > 1 && !m.sidebar
+			return m, nil
+
+		case "up", "k", "down", "j":
+			if !m.sidebar {
+				break
+			}
+			if keypress == "up" || keypress == "k" {
+				m.folder = (m.folder + len(m.folders) - 1) % len(m.folders)
+			} else {
+				m.folder = (m.folder + 1) % len(m.folders)
+			}
+			return m, openFolder(m.lib, m.folders, m.folder)
+
 		case "enter":
+			if m.sidebar {
+				m.sidebar = false
+				return m, nil
+			}
 			i, ok := m.list.SelectedItem().(item)
 			if ok {
-				m.choice = string(i)
+				m.choice = i.bookmark.Title
 			}
 			return m, tea.Quit
 		}
@@ -93,29 +224,97 @@ func (m model) View() string {
 	if m.quitting {
 		return quitTextStyle.Render("Bye!")
 	}
-	return "\n" + m.list.View()
+	view := m.list.View()
+	if m.err != nil {
+		view = quitTextStyle.Render(fmt.Sprintf("failed to open %s: %v", m.folders[m.folder].name, m.err))
+	}
+	if sidebar := m.sidebarView(); sidebar != "" {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, sidebar, view)
+	}
+	return "\n" + view
+}
+
+// sidebarView renders the folders, or nothing when there are no
+// collections or saved searches. Collections are indented below the
+// collections they are in.
+func (m model) sidebarView() string {
+	if len(m.folders) < 2 { //nolint:mnd // all bookmarks and another folder
+		return ""
+	}
+	lines := make([]string, 0, len(m.folders))
+	for i, f := range m.folders {
+		name := f.name
+		if f.collection != "" {
+			names := bookmark.SplitCollection(f.collection)
+			name = strings.Repeat("  ", len(names)) + names[len(names)-1]
+		}
+		if f.search != "" {
+			name = searchFolderStyle.Render(name)
+		}
+		switch {
+		case i == m.folder && m.sidebar:
+			lines = append(lines, activeFolderStyle.Render("> "+name))
+		case i == m.folder:
+			lines = append(lines, activeFolderStyle.Render("  "+name))
+		default:
+			lines = append(lines, folderStyle.Render("  "+name))
+		}
+	}
+	return sidebarStyle.Height(listHeight - 1).Render(strings.Join(lines, "\n"))
 }
 
-func Run(lib *bookmark.Library) error {
+func Run(ctx context.Context, lib *bookmark.Library) error {
 	items := []list.Item{}
 	bookmarks, err := lib.List()
 	if err != nil {
 		return fmt.Errorf("failed to list bookmarks: %w", err)
 	}
+	collections, err := lib.Collections()
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	searches, err := lib.SavedSearches()
+	if err != nil {
+		return fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	folders := []folder{{name: allBookmarks}}
+	for _, c := range collections {
+		folders = append(folders, folder{name: c, collection: c})
+	}
+	for _, s := range searches {
+		folders = append(folders, folder{name: s.Name, search: s.Name})
+	}
 	for _, b := range bookmarks {
-		items = append(items, item(b.Title))
+		items = append(items, item{bookmark: b})
 	}
 	const defaultWidth = 20
 	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
-	l.Title = "My bookmarks"
+	l.Title = allBookmarks
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
-	m := model{list: l}
-	if _, err = tea.NewProgram(m).Run(); err != nil {
-		return err
+	if len(folders) > 1 {
+		l.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "folders"))}
+		}
 	}
-	return nil
+	// the pending metadata is fetched in the background, which stops when
+	// the program exits, so the library is no longer used once Run returns
+	ctx, cancel := context.WithCancel(ctx)
+	p := tea.NewProgram(model{lib: lib, list: l, folders: folders}, tea.WithContext(ctx))
+	var wg sync.WaitGroup
+	if pending := pendingBookmarks(bookmarks); len(pending) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// sending to a program that exited does nothing
+			p.Send(refreshPending(ctx, lib, pending))
+		}()
+	}
+	_, err = p.Run()
+	cancel()
+	wg.Wait()
+	return err
 }